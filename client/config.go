@@ -0,0 +1,237 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+//------------------------------------------------------------------------------
+
+// Config defines kubernetes client configuration shared by all plugins
+type Config struct {
+	Discovery           DiscoveryConfig        `json:"discovery" yaml:"discovery"`
+	Kubeconfig          KubeconfigConfig       `json:"kubeconfig" yaml:"kubeconfig"`
+	RestConfigOverrides map[string]interface{} `json:"rest_config_overrides,omitempty" yaml:"rest_config_overrides,omitempty"`
+	Scheme              SchemeConfig           `json:"scheme" yaml:"scheme"`
+	TLS                 TLSConfig              `json:"tls" yaml:"tls"`
+	UserAgent           string                 `json:"user_agent" yaml:"user_agent"`
+}
+
+// NewConfig returns a Config with default values
+func NewConfig() Config {
+	return Config{
+		Discovery:  NewDiscoveryConfig(),
+		Kubeconfig: NewKubeconfigConfig(),
+		Scheme:     NewSchemeConfig(),
+		TLS:        NewTLSConfig(),
+	}
+}
+
+// TLSConfig defines custom TLS settings for the API server connection
+type TLSConfig struct {
+	CAFile             string `json:"ca_file" yaml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	KeyFile            string `json:"key_file" yaml:"key_file"`
+}
+
+// NewTLSConfig returns a TLSConfig with default values
+func NewTLSConfig() TLSConfig {
+	return TLSConfig{}
+}
+
+// Apply populates the TLS client config of the given rest config, taking
+// precedence over any TLS settings loaded from the environment
+func (t TLSConfig) Apply(rc *rest.Config) error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("cert_file and key_file must be specified together")
+	}
+	if t.InsecureSkipVerify && t.CAFile != "" {
+		return errors.New("ca_file cannot be specified with insecure_skip_verify")
+	}
+
+	// inline data takes precedence over files in client-go, so it must be
+	// discarded when a file is specified
+	if t.CAFile != "" {
+		rc.TLSClientConfig.CAFile = t.CAFile
+		rc.TLSClientConfig.CAData = nil
+	}
+	if t.CertFile != "" {
+		rc.TLSClientConfig.CertFile = t.CertFile
+		rc.TLSClientConfig.CertData = nil
+		rc.TLSClientConfig.KeyFile = t.KeyFile
+		rc.TLSClientConfig.KeyData = nil
+	}
+	if t.InsecureSkipVerify {
+		rc.TLSClientConfig.Insecure = true
+		rc.TLSClientConfig.CAFile = ""
+		rc.TLSClientConfig.CAData = nil
+	}
+	return nil
+}
+
+// RestConfig builds a kubernetes rest config by loading the base config from
+// the configured kubeconfig, or the environment (kubeconfig or in-cluster), and
+// applying any configured overrides, forwarding any API server warnings to the
+// logger and metrics. The component identifies the plugin in the default user
+// agent.
+func (c Config) RestConfig(component string, log log.Modular, stats metrics.Type) (*rest.Config, error) {
+	if c.Kubeconfig.Reload && c.Kubeconfig.Path == "" {
+		return nil, errors.New("kubeconfig reload requires a kubeconfig path")
+	}
+
+	rc, err := c.baseRestConfig(component, log)
+	if err != nil {
+		return nil, err
+	}
+
+	// reloaded transports are built using the same overrides as the original
+	if c.Kubeconfig.Reload {
+		rc.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return newReloadingRoundTripper(rt, c.Kubeconfig.Path, func() (*rest.Config, error) {
+				return c.baseRestConfig(component, log)
+			}, log)
+		})
+	}
+
+	rc.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return newWarningRoundTripper(rt, log, stats)
+	})
+
+	return rc, nil
+}
+
+// baseRestConfig loads the base rest config and applies any configured
+// overrides
+func (c Config) baseRestConfig(component string, log log.Modular) (*rest.Config, error) {
+	var rc *rest.Config
+	var err error
+	if c.Kubeconfig.Path != "" {
+		rc, err = c.Kubeconfig.load()
+	} else {
+		rc, err = config.GetConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubernetes config: %v", err)
+	}
+	rc.UserAgent = DefaultUserAgent(component)
+
+	if err := c.TLS.Apply(rc); err != nil {
+		return nil, fmt.Errorf("error applying tls config: %v", err)
+	}
+
+	if len(c.RestConfigOverrides) > 0 {
+		if err := applyRestConfigOverrides(rc, c.RestConfigOverrides, log); err != nil {
+			return nil, fmt.Errorf("error applying rest_config_overrides: %v", err)
+		}
+	}
+
+	if c.UserAgent != "" {
+		rc.UserAgent = c.UserAgent
+	}
+	return rc, nil
+}
+
+//------------------------------------------------------------------------------
+
+// restConfigOverrides defines the subset of rest config fields that are safe
+// to override
+type restConfigOverrides struct {
+	APIPath            *string  `json:"api_path"`
+	Burst              *int     `json:"burst"`
+	DisableCompression *bool    `json:"disable_compression"`
+	Host               *string  `json:"host"`
+	Impersonate        *string  `json:"impersonate"`
+	ImpersonateGroups  []string `json:"impersonate_groups"`
+	Insecure           *bool    `json:"insecure"`
+	QPS                *float32 `json:"qps"`
+	ServerName         *string  `json:"server_name"`
+	Timeout            *string  `json:"timeout"`
+	UserAgent          *string  `json:"user_agent"`
+}
+
+// applyRestConfigOverrides decodes the raw overrides onto the given rest
+// config, ignoring unknown keys with a warning
+func applyRestConfigOverrides(rc *rest.Config, raw map[string]interface{}, log log.Modular) error {
+	known := map[string]struct{}{}
+	t := reflect.TypeOf(restConfigOverrides{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		known[name] = struct{}{}
+	}
+
+	var unknown []string
+	for k := range raw {
+		if _, ok := known[k]; !ok {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		log.Warnf("ignoring unsupported rest_config_overrides keys: %s", strings.Join(unknown, ", "))
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	var o restConfigOverrides
+	if err := json.Unmarshal(b, &o); err != nil {
+		return err
+	}
+
+	if o.APIPath != nil {
+		rc.APIPath = *o.APIPath
+	}
+	if o.Burst != nil {
+		rc.Burst = *o.Burst
+	}
+	if o.DisableCompression != nil {
+		rc.DisableCompression = *o.DisableCompression
+	}
+	if o.Host != nil {
+		rc.Host = *o.Host
+	}
+	if o.Impersonate != nil {
+		rc.Impersonate.UserName = *o.Impersonate
+	}
+	if o.ImpersonateGroups != nil {
+		rc.Impersonate.Groups = o.ImpersonateGroups
+	}
+	if o.Insecure != nil {
+		rc.TLSClientConfig.Insecure = *o.Insecure
+		// client-go rejects root certificates combined with the insecure flag
+		if rc.TLSClientConfig.Insecure {
+			rc.TLSClientConfig.CAFile = ""
+			rc.TLSClientConfig.CAData = nil
+		}
+	}
+	if o.QPS != nil {
+		rc.QPS = *o.QPS
+	}
+	if o.ServerName != nil {
+		rc.TLSClientConfig.ServerName = *o.ServerName
+	}
+	if o.Timeout != nil {
+		timeout, err := time.ParseDuration(*o.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %v", err)
+		}
+		rc.Timeout = timeout
+	}
+	if o.UserAgent != nil {
+		rc.UserAgent = *o.UserAgent
+	}
+	return nil
+}
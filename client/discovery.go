@@ -0,0 +1,115 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/restmapper"
+)
+
+//------------------------------------------------------------------------------
+
+// DiscoveryConfig defines how the results of the discovery api are cached
+type DiscoveryConfig struct {
+	InvalidateOnMissingKind bool `json:"invalidate_on_missing_kind" yaml:"invalidate_on_missing_kind"`
+}
+
+// NewDiscoveryConfig returns a DiscoveryConfig with default values
+func NewDiscoveryConfig() DiscoveryConfig {
+	return DiscoveryConfig{
+		InvalidateOnMissingKind: true,
+	}
+}
+
+// discoveryInvalidateInterval is the minimum interval between invalidations
+// of the discovery cache triggered by missing kinds, which bounds the
+// discovery traffic caused by repeated lookups of kinds that are not served
+const discoveryInvalidateInterval = 10 * time.Second
+
+//------------------------------------------------------------------------------
+
+// invalidatingRESTMapper is a rest mapper backed by the cached discovery
+// client that invalidates the cache and retries once when a kind is not found,
+// such that kinds installed after the cache was populated (e.g. new custom
+// resources) are discovered
+type invalidatingRESTMapper struct {
+	*restmapper.DeferredDiscoveryRESTMapper
+	log log.Modular
+
+	mut         sync.Mutex
+	invalidated time.Time
+}
+
+// invalidate resets the discovery cache following a missing kind, returning
+// false if the cache was invalidated too recently
+func (m *invalidatingRESTMapper) invalidate(err error) bool {
+	if !meta.IsNoMatchError(err) {
+		return false
+	}
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if time.Since(m.invalidated) < discoveryInvalidateInterval {
+		return false
+	}
+	m.invalidated = time.Now()
+	m.log.Debugf("invalidating discovery cache: %v", err)
+	m.Reset()
+	return true
+}
+
+// KindFor implements meta.RESTMapper
+func (m *invalidatingRESTMapper) KindFor(resource schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	gvk, err := m.DeferredDiscoveryRESTMapper.KindFor(resource)
+	if m.invalidate(err) {
+		return m.DeferredDiscoveryRESTMapper.KindFor(resource)
+	}
+	return gvk, err
+}
+
+// KindsFor implements meta.RESTMapper
+func (m *invalidatingRESTMapper) KindsFor(resource schema.GroupVersionResource) ([]schema.GroupVersionKind, error) {
+	gvks, err := m.DeferredDiscoveryRESTMapper.KindsFor(resource)
+	if m.invalidate(err) {
+		return m.DeferredDiscoveryRESTMapper.KindsFor(resource)
+	}
+	return gvks, err
+}
+
+// ResourceFor implements meta.RESTMapper
+func (m *invalidatingRESTMapper) ResourceFor(input schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+	gvr, err := m.DeferredDiscoveryRESTMapper.ResourceFor(input)
+	if m.invalidate(err) {
+		return m.DeferredDiscoveryRESTMapper.ResourceFor(input)
+	}
+	return gvr, err
+}
+
+// ResourcesFor implements meta.RESTMapper
+func (m *invalidatingRESTMapper) ResourcesFor(input schema.GroupVersionResource) ([]schema.GroupVersionResource, error) {
+	gvrs, err := m.DeferredDiscoveryRESTMapper.ResourcesFor(input)
+	if m.invalidate(err) {
+		return m.DeferredDiscoveryRESTMapper.ResourcesFor(input)
+	}
+	return gvrs, err
+}
+
+// RESTMapping implements meta.RESTMapper
+func (m *invalidatingRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	mapping, err := m.DeferredDiscoveryRESTMapper.RESTMapping(gk, versions...)
+	if m.invalidate(err) {
+		return m.DeferredDiscoveryRESTMapper.RESTMapping(gk, versions...)
+	}
+	return mapping, err
+}
+
+// RESTMappings implements meta.RESTMapper
+func (m *invalidatingRESTMapper) RESTMappings(gk schema.GroupKind, versions ...string) ([]*meta.RESTMapping, error) {
+	mappings, err := m.DeferredDiscoveryRESTMapper.RESTMappings(gk, versions...)
+	if m.invalidate(err) {
+		return m.DeferredDiscoveryRESTMapper.RESTMappings(gk, versions...)
+	}
+	return mappings, err
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Classes of API errors, reported via kubernetes.error.<class> metrics
+const (
+	ErrorAlreadyExists      = "already_exists"
+	ErrorConflict           = "conflict"
+	ErrorForbidden          = "forbidden"
+	ErrorInternal           = "internal"
+	ErrorInvalid            = "invalid"
+	ErrorNotFound           = "not_found"
+	ErrorOther              = "other"
+	ErrorServiceUnavailable = "service_unavailable"
+	ErrorTimeout            = "timeout"
+	ErrorTooManyRequests    = "too_many_requests"
+	ErrorUnauthorized       = "unauthorized"
+)
+
+var errorClasses = []string{
+	ErrorAlreadyExists,
+	ErrorConflict,
+	ErrorForbidden,
+	ErrorInternal,
+	ErrorInvalid,
+	ErrorNotFound,
+	ErrorOther,
+	ErrorServiceUnavailable,
+	ErrorTimeout,
+	ErrorTooManyRequests,
+	ErrorUnauthorized,
+}
+
+// ErrorClass classifies an error returned by the API server
+func ErrorClass(err error) string {
+	switch {
+	case apierrors.IsForbidden(err):
+		return ErrorForbidden
+	case apierrors.IsUnauthorized(err):
+		return ErrorUnauthorized
+	case apierrors.IsNotFound(err):
+		return ErrorNotFound
+	case apierrors.IsAlreadyExists(err):
+		return ErrorAlreadyExists
+	case apierrors.IsConflict(err):
+		return ErrorConflict
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return ErrorInvalid
+	case apierrors.IsTooManyRequests(err):
+		return ErrorTooManyRequests
+	case apierrors.IsServerTimeout(err), apierrors.IsTimeout(err):
+		return ErrorTimeout
+	case apierrors.IsServiceUnavailable(err):
+		return ErrorServiceUnavailable
+	case apierrors.IsInternalError(err):
+		return ErrorInternal
+	default:
+		return ErrorOther
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ErrorCounter counts API errors by class, such that e.g. RBAC errors can be
+// distinguished from transient server errors
+type ErrorCounter struct {
+	counters map[string]metrics.StatCounter
+}
+
+// NewErrorCounter returns an ErrorCounter that registers a
+// kubernetes.error.<class> counter for each error class
+func NewErrorCounter(stats metrics.Type) *ErrorCounter {
+	c := &ErrorCounter{
+		counters: make(map[string]metrics.StatCounter, len(errorClasses)),
+	}
+	for _, class := range errorClasses {
+		c.counters[class] = stats.GetCounter("kubernetes.error." + class)
+	}
+	return c
+}
+
+// Incr increments the counter of the class of the given error, if not nil
+func (c *ErrorCounter) Incr(err error) {
+	if err == nil {
+		return
+	}
+	c.counters[ErrorClass(err)].Incr(1)
+}
+
+//------------------------------------------------------------------------------
+
+// NewInstrumentedClient returns a client that counts the errors returned by
+// the given client
+func NewInstrumentedClient(c client.Client, counter *ErrorCounter) client.Client {
+	return &instrumentedClient{Client: c, counter: counter}
+}
+
+type instrumentedClient struct {
+	client.Client
+	counter *ErrorCounter
+}
+
+func (c *instrumentedClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	err := c.Client.Get(ctx, key, obj)
+	c.counter.Incr(err)
+	return err
+}
+
+func (c *instrumentedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	err := c.Client.List(ctx, list, opts...)
+	c.counter.Incr(err)
+	return err
+}
+
+func (c *instrumentedClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	err := c.Client.Create(ctx, obj, opts...)
+	c.counter.Incr(err)
+	return err
+}
+
+func (c *instrumentedClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	err := c.Client.Delete(ctx, obj, opts...)
+	c.counter.Incr(err)
+	return err
+}
+
+func (c *instrumentedClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	err := c.Client.Update(ctx, obj, opts...)
+	c.counter.Incr(err)
+	return err
+}
+
+func (c *instrumentedClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	err := c.Client.Patch(ctx, obj, patch, opts...)
+	c.counter.Incr(err)
+	return err
+}
+
+func (c *instrumentedClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	err := c.Client.DeleteAllOf(ctx, obj, opts...)
+	c.counter.Incr(err)
+	return err
+}
+
+func (c *instrumentedClient) Status() client.StatusWriter {
+	return &instrumentedStatusWriter{StatusWriter: c.Client.Status(), counter: c.counter}
+}
+
+type instrumentedStatusWriter struct {
+	client.StatusWriter
+	counter *ErrorCounter
+}
+
+func (w *instrumentedStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	err := w.StatusWriter.Update(ctx, obj, opts...)
+	w.counter.Incr(err)
+	return err
+}
+
+func (w *instrumentedStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	err := w.StatusWriter.Patch(ctx, obj, patch, opts...)
+	w.counter.Incr(err)
+	return err
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestErrorClass(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	gk := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	tests := []struct {
+		name  string
+		err   error
+		class string
+	}{
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "a", errors.New(`User "system:serviceaccount:default:benthos" cannot get resource "deployments"`)), class: ErrorForbidden},
+		{name: "unauthorized", err: apierrors.NewUnauthorized("Unauthorized"), class: ErrorUnauthorized},
+		{name: "not found", err: apierrors.NewNotFound(gr, "a"), class: ErrorNotFound},
+		{name: "already exists", err: apierrors.NewAlreadyExists(gr, "a"), class: ErrorAlreadyExists},
+		{name: "conflict", err: apierrors.NewConflict(gr, "a", errors.New("the object has been modified; please apply your changes to the latest version and try again")), class: ErrorConflict},
+		{name: "apply conflict", err: apierrors.NewApplyConflict(nil, `Apply failed with 1 conflict: conflict with "kubectl"`), class: ErrorConflict},
+		{name: "invalid", err: apierrors.NewInvalid(gk, "a", field.ErrorList{field.Invalid(field.NewPath("spec", "selector"), nil, "field is immutable")}), class: ErrorInvalid},
+		{name: "bad request", err: apierrors.NewBadRequest("the server rejected our request for an unknown reason"), class: ErrorInvalid},
+		{name: "too many requests", err: apierrors.NewTooManyRequests("the server has received too many requests and has asked us to try again later", 1), class: ErrorTooManyRequests},
+		{name: "server timeout", err: apierrors.NewServerTimeout(gr, "list", 1), class: ErrorTimeout},
+		{name: "timeout", err: apierrors.NewTimeoutError("request did not complete within requested timeout 30s", 0), class: ErrorTimeout},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("the server is currently unable to handle the request"), class: ErrorServiceUnavailable},
+		{name: "internal", err: apierrors.NewInternalError(errors.New("etcdserver: request timed out")), class: ErrorInternal},
+		{name: "gone", err: apierrors.NewGone("too old resource version"), class: ErrorOther},
+		{name: "non status error", err: errors.New("dial tcp 10.0.0.1:443: connect: connection refused"), class: ErrorOther},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if class := ErrorClass(test.err); class != test.class {
+				t.Errorf("expected class %s, got %s", test.class, class)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubeconfigCheckInterval is the minimum interval between checks for changes
+// to a reloaded kubeconfig, which is additionally checked on every 401
+const kubeconfigCheckInterval = 10 * time.Second
+
+//------------------------------------------------------------------------------
+
+// KubeconfigConfig defines an explicit kubeconfig file used in place of the
+// kubeconfig or in-cluster config loaded from the environment
+type KubeconfigConfig struct {
+	Path   string `json:"path" yaml:"path"`
+	Reload bool   `json:"reload" yaml:"reload"`
+}
+
+// NewKubeconfigConfig returns a KubeconfigConfig with default values
+func NewKubeconfigConfig() KubeconfigConfig {
+	return KubeconfigConfig{}
+}
+
+// load returns the rest config of the configured kubeconfig file
+func (k KubeconfigConfig) load() (*rest.Config, error) {
+	return clientcmd.BuildConfigFromFlags("", k.Path)
+}
+
+//------------------------------------------------------------------------------
+
+// reloadingRoundTripper replaces the transport of a rest config with one built
+// from the latest contents of its kubeconfig file once the file changes (e.g.
+// when the kubeconfig is mounted from a rotated Secret), such that rotated
+// credentials are used without restarting the client. Requests that fail with
+// 401 trigger an immediate check, and are retried once if the kubeconfig has
+// changed.
+type reloadingRoundTripper struct {
+	base http.RoundTripper
+	path string
+	load func() (*rest.Config, error)
+	log  log.Modular
+
+	mut     sync.Mutex
+	checked time.Time
+	modTime time.Time
+	current http.RoundTripper
+}
+
+func newReloadingRoundTripper(rt http.RoundTripper, path string, load func() (*rest.Config, error), log log.Modular) *reloadingRoundTripper {
+	r := &reloadingRoundTripper{
+		base:    rt,
+		path:    path,
+		load:    load,
+		log:     log,
+		checked: time.Now(),
+	}
+	if info, err := os.Stat(path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *reloadingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := r.transport(false)
+	res, err := r.roundTrip(rt, req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// credentials may have been rotated since the last check
+	next := r.transport(true)
+	if next == rt {
+		return res, err
+	}
+	retry, ok := rewindRequest(req)
+	if !ok {
+		return res, err
+	}
+	res.Body.Close()
+	return r.roundTrip(next, retry)
+}
+
+// roundTrip sends a request using the given transport. Credentials are added
+// to requests by the rest config prior to reaching this round tripper, so
+// they are discarded in favor of those of reloaded transports.
+func (r *reloadingRoundTripper) roundTrip(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if rt == r.base {
+		return rt.RoundTrip(req)
+	}
+	req = utilnet.CloneRequest(req)
+	req.Header.Del("Authorization")
+	return rt.RoundTrip(req)
+}
+
+// transport returns the transport of the latest kubeconfig, reloading it if
+// the kubeconfig has changed since the last check
+func (r *reloadingRoundTripper) transport(force bool) http.RoundTripper {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	current := r.base
+	if r.current != nil {
+		current = r.current
+	}
+	now := time.Now()
+	if !force && now.Sub(r.checked) < kubeconfigCheckInterval {
+		return current
+	}
+	r.checked = now
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		r.log.Warnf("error checking kubeconfig %s: %v", r.path, err)
+		return current
+	}
+	if info.ModTime().Equal(r.modTime) {
+		return current
+	}
+	rc, err := r.load()
+	if err != nil {
+		r.log.Warnf("error reloading kubeconfig %s: %v", r.path, err)
+		return current
+	}
+	rt, err := rest.TransportFor(rc)
+	if err != nil {
+		r.log.Warnf("error reloading kubeconfig %s: %v", r.path, err)
+		return current
+	}
+	r.modTime = info.ModTime()
+	r.current = rt
+	r.log.Infof("reloaded kubeconfig %s", r.path)
+	return rt
+}
+
+// rewindRequest returns a copy of a request that can be sent again, or false
+// if its body cannot be replayed
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return utilnet.CloneRequest(req), true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry := utilnet.CloneRequest(req)
+	retry.Body = body
+	return retry, true
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/manager"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	manager.RegisterPlugin(
+		"kubernetes",
+		func() interface{} {
+			conf := NewConfig()
+			return &conf
+		},
+		func(iconf interface{}, mgr types.Manager, logger log.Modular, stats metrics.Type) (interface{}, error) {
+			conf, ok := iconf.(*Config)
+			if !ok {
+				return nil, errors.New("failed to cast config")
+			}
+			return NewResource(*conf, "resource", logger, stats), nil
+		},
+	)
+
+	manager.DocumentPlugin(
+		"kubernetes",
+		`
+This plugin defines a kubernetes client that can be shared by the kubernetes
+input, outputs, and processor by referencing it by name via their client field.`,
+		nil,
+	)
+}
+
+//------------------------------------------------------------------------------
+
+// Resource is a kubernetes client that can be shared between plugins, such
+// that the rest config, rest mapper, and client are only initialized once
+type Resource struct {
+	conf      Config
+	component string
+	log       log.Modular
+	stats     metrics.Type
+
+	mut        sync.Mutex
+	restConfig *rest.Config
+	scheme     *runtime.Scheme
+	static     meta.RESTMapper
+	discovery  discovery.CachedDiscoveryInterface
+	mapper     meta.RESTMapper
+	client     client.Client
+	clientset  kubernetes.Interface
+	dynamic    dynamic.Interface
+	caches     map[schema.GroupVersionKind]cacheEntry
+	resolved   map[schema.GroupVersionKind]schema.GroupVersionKind
+
+	statusSubresources map[schema.GroupVersionKind]bool
+}
+
+// cacheEntry is an informer cache registered for a single GVK
+type cacheEntry struct {
+	reader client.Reader
+	typed  bool
+}
+
+// NewResource returns a new, uninitialized Resource, where component
+// identifies the owning plugin in the default user agent
+func NewResource(conf Config, component string, log log.Modular, stats metrics.Type) *Resource {
+	return &Resource{
+		conf:      conf,
+		component: component,
+		log:       log,
+		stats:     stats,
+	}
+}
+
+// GetResource returns the named kubernetes resource plugin if name is not
+// empty, otherwise a new Resource built from the given config
+func GetResource(mgr types.Manager, name, component string, conf Config, log log.Modular, stats metrics.Type) (*Resource, error) {
+	if name == "" {
+		return NewResource(conf, component, log, stats), nil
+	}
+	p, err := mgr.GetPlugin(name)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving client resource %s: %v", name, err)
+	}
+	r, ok := p.(*Resource)
+	if !ok {
+		return nil, fmt.Errorf("resource %s is not a kubernetes client", name)
+	}
+	return r, nil
+}
+
+// RestConfig returns a copy of the shared rest config, loading it on first
+// use
+func (r *Resource) RestConfig() (*rest.Config, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	rc, err := r.loadRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	return rest.CopyConfig(rc), nil
+}
+
+// Mapper returns the shared rest mapper, initializing it on first use
+func (r *Resource) Mapper() (meta.RESTMapper, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return r.loadMapper()
+}
+
+// Scheme returns the shared runtime scheme, which includes any configured
+// types, initializing it on first use
+func (r *Resource) Scheme() (*runtime.Scheme, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return r.loadScheme()
+}
+
+// Client returns the shared client, initializing it on first use
+func (r *Resource) Client() (client.Client, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.client != nil {
+		return r.client, nil
+	}
+	rc, err := r.loadRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	mapper, err := r.loadMapper()
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := r.loadScheme()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(rc, client.Options{Scheme: scheme, Mapper: mapper})
+	if err != nil {
+		return nil, fmt.Errorf("error initializing client: %v", err)
+	}
+	r.client = c
+	return c, nil
+}
+
+// Clientset returns the shared typed clientset, which is required by
+// operations that are not supported by the controller-runtime client (e.g.
+// logs, evictions, and token requests), initializing it on first use
+func (r *Resource) Clientset() (kubernetes.Interface, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.clientset != nil {
+		return r.clientset, nil
+	}
+	rc, err := r.loadRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing clientset: %v", err)
+	}
+	r.clientset = clientset
+	return clientset, nil
+}
+
+// Dynamic returns the shared dynamic client, which supports arbitrary
+// subresources (e.g. scale), initializing it on first use
+func (r *Resource) Dynamic() (dynamic.Interface, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.dynamic != nil {
+		return r.dynamic, nil
+	}
+	rc, err := r.loadRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	d, err := dynamic.NewForConfig(rc)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing dynamic client: %v", err)
+	}
+	r.dynamic = d
+	return d, nil
+}
+
+// RegisterCache registers a synced informer cache, e.g. that of a kubernetes
+// input watching the given GVK, such that it can be used by other plugins for
+// read-only lookups. Typed indicates whether the cache holds typed objects
+// rather than unstructured objects.
+func (r *Resource) RegisterCache(gvk schema.GroupVersionKind, reader client.Reader, typed bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.caches == nil {
+		r.caches = map[schema.GroupVersionKind]cacheEntry{}
+	}
+	r.caches[gvk] = cacheEntry{reader: reader, typed: typed}
+}
+
+// UnregisterCache removes a previously registered informer cache, if it is
+// still registered for the given GVK
+func (r *Resource) UnregisterCache(gvk schema.GroupVersionKind, reader client.Reader) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if e, ok := r.caches[gvk]; ok && e.reader == reader {
+		delete(r.caches, gvk)
+	}
+}
+
+// Cache returns the informer cache registered for the given GVK, if any, and
+// whether it holds typed objects
+func (r *Resource) Cache(gvk schema.GroupVersionKind) (reader client.Reader, typed bool, ok bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	e, ok := r.caches[gvk]
+	return e.reader, e.typed, ok
+}
+
+func (r *Resource) loadRestConfig() (*rest.Config, error) {
+	if r.restConfig != nil {
+		return r.restConfig, nil
+	}
+	rc, err := r.conf.RestConfig(r.component, r.log, r.stats)
+	if err != nil {
+		return nil, err
+	}
+	r.restConfig = rc
+	return rc, nil
+}
+
+func (r *Resource) loadScheme() (*runtime.Scheme, error) {
+	if r.scheme != nil {
+		return r.scheme, nil
+	}
+	scheme, static, err := r.conf.Scheme.Build()
+	if err != nil {
+		return nil, fmt.Errorf("error building scheme: %v", err)
+	}
+	r.scheme = scheme
+	r.static = static
+	return scheme, nil
+}
+
+// loadDiscovery returns the discovery client, which caches discovery results
+// in memory such that they are fetched once and shared by the rest mapper and
+// all other discovery lookups
+func (r *Resource) loadDiscovery() (discovery.CachedDiscoveryInterface, error) {
+	if r.discovery != nil {
+		return r.discovery, nil
+	}
+	rc, err := r.loadRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	d, err := discovery.NewDiscoveryClientForConfig(rc)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing discovery client: %v", err)
+	}
+	r.discovery = memory.NewMemCacheClient(d)
+	return r.discovery, nil
+}
+
+// Discovery returns the shared cached discovery client, initializing it on
+// first use
+func (r *Resource) Discovery() (discovery.CachedDiscoveryInterface, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return r.loadDiscovery()
+}
+
+func (r *Resource) loadMapper() (meta.RESTMapper, error) {
+	if r.mapper != nil {
+		return r.mapper, nil
+	}
+	d, err := r.loadDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.loadScheme(); err != nil {
+		return nil, err
+	}
+	// discovery is deferred until the first lookup
+	deferred := restmapper.NewDeferredDiscoveryRESTMapper(d)
+	var mapper meta.RESTMapper = deferred
+	if r.conf.Discovery.InvalidateOnMissingKind {
+		mapper = &invalidatingRESTMapper{DeferredDiscoveryRESTMapper: deferred, log: r.log}
+	}
+	// fall back to statically configured kinds that are not (yet) known to
+	// the discovery api
+	if r.static != nil {
+		mapper = &fallbackRESTMapper{RESTMapper: mapper, fallback: r.static}
+	}
+	r.mapper = mapper
+	return mapper, nil
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+//------------------------------------------------------------------------------
+
+// SchemeConfig defines additional API types to register with the client
+type SchemeConfig struct {
+	CRDDirectory string       `json:"crd_directory" yaml:"crd_directory"`
+	Kinds        []KindConfig `json:"kinds" yaml:"kinds"`
+}
+
+// NewSchemeConfig returns a SchemeConfig with default values
+func NewSchemeConfig() SchemeConfig {
+	return SchemeConfig{
+		Kinds: []KindConfig{},
+	}
+}
+
+// KindConfig identifies a single API type to register
+type KindConfig struct {
+	ClusterScoped bool   `json:"cluster_scoped" yaml:"cluster_scoped"`
+	Group         string `json:"group" yaml:"group"`
+	Kind          string `json:"kind" yaml:"kind"`
+	Plural        string `json:"plural" yaml:"plural"`
+	Version       string `json:"version" yaml:"version"`
+}
+
+//------------------------------------------------------------------------------
+
+// Build returns a runtime scheme containing the built-in kubernetes types
+// (including CustomResourceDefinitions) and any configured types registered as
+// unstructured, along with a static rest mapper for the configured types
+func (c SchemeConfig) Build() (*runtime.Scheme, meta.RESTMapper, error) {
+	kinds := append([]KindConfig{}, c.Kinds...)
+	if c.CRDDirectory != "" {
+		crdKinds, err := loadCRDKinds(c.CRDDirectory)
+		if err != nil {
+			return nil, nil, err
+		}
+		kinds = append(kinds, crdKinds...)
+	}
+
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		apiextensionsv1.AddToScheme,
+		apiextensionsv1beta1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(kinds) == 0 {
+		return scheme, nil, nil
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, k := range kinds {
+		if k.Kind == "" || k.Version == "" {
+			return nil, nil, fmt.Errorf("kind and version are required: %+v", k)
+		}
+		gvk := schema.GroupVersionKind{Group: k.Group, Version: k.Version, Kind: k.Kind}
+		if !scheme.Recognizes(gvk) {
+			scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+			scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+		}
+
+		scope := meta.RESTScopeNamespace
+		if k.ClusterScoped {
+			scope = meta.RESTScopeRoot
+		}
+		if k.Plural == "" {
+			mapper.Add(gvk, scope)
+			continue
+		}
+		plural := gvk.GroupVersion().WithResource(k.Plural)
+		singular := gvk.GroupVersion().WithResource(strings.ToLower(gvk.Kind))
+		mapper.AddSpecific(gvk, plural, singular, scope)
+	}
+	return scheme, mapper, nil
+}
+
+// loadCRDKinds parses the served versions of all CustomResourceDefinitions
+// found in the YAML or JSON files of the given directory
+func loadCRDKinds(dir string) ([]KindConfig, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading crd_directory: %v", err)
+	}
+
+	var kinds []KindConfig
+	for _, f := range files {
+		switch filepath.Ext(f.Name()) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		fileKinds, err := parseCRDFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		kinds = append(kinds, fileKinds...)
+	}
+	return kinds, nil
+}
+
+// parseCRDFile parses the kinds defined by all CustomResourceDefinitions in a
+// single, possibly multi-document, file
+func parseCRDFile(path string) ([]KindConfig, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var kinds []KindConfig
+	reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		jb, err := yaml.ToJSON(doc)
+		if err != nil {
+			return nil, err
+		}
+		if string(jb) == "null" {
+			continue
+		}
+
+		var u unstructured.Unstructured
+		if err := u.UnmarshalJSON(jb); err != nil {
+			return nil, err
+		}
+		if u.GetKind() != "CustomResourceDefinition" {
+			continue
+		}
+
+		group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(u.Object, "spec", "names", "kind")
+		plural, _, _ := unstructured.NestedString(u.Object, "spec", "names", "plural")
+		scope, _, _ := unstructured.NestedString(u.Object, "spec", "scope")
+
+		// apiextensions.k8s.io/v1beta1 supports a single top-level version
+		var versions []string
+		if v, ok, _ := unstructured.NestedString(u.Object, "spec", "version"); ok && v != "" {
+			versions = append(versions, v)
+		}
+		items, _, _ := unstructured.NestedSlice(u.Object, "spec", "versions")
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if served, ok := m["served"].(bool); ok && !served {
+				continue
+			}
+			if name, ok := m["name"].(string); ok && name != "" {
+				versions = append(versions, name)
+			}
+		}
+
+		seen := map[string]struct{}{}
+		for _, v := range versions {
+			if _, ok := seen[v]; ok {
+				continue
+			}
+			seen[v] = struct{}{}
+			kinds = append(kinds, KindConfig{
+				ClusterScoped: scope == "Cluster",
+				Group:         group,
+				Kind:          kind,
+				Plural:        plural,
+				Version:       v,
+			})
+		}
+	}
+	return kinds, nil
+}
+
+//------------------------------------------------------------------------------
+
+// fallbackRESTMapper resolves mappings using a primary (discovery based) rest
+// mapper, falling back to a static mapper for kinds unknown to the primary
+type fallbackRESTMapper struct {
+	meta.RESTMapper
+	fallback meta.RESTMapper
+}
+
+// RESTMapping implements meta.RESTMapper
+func (m *fallbackRESTMapper) RESTMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	mapping, err := m.RESTMapper.RESTMapping(gk, versions...)
+	if meta.IsNoMatchError(err) {
+		return m.fallback.RESTMapping(gk, versions...)
+	}
+	return mapping, err
+}
+
+// RESTMappings implements meta.RESTMapper
+func (m *fallbackRESTMapper) RESTMappings(gk schema.GroupKind, versions ...string) ([]*meta.RESTMapping, error) {
+	mappings, err := m.RESTMapper.RESTMappings(gk, versions...)
+	if meta.IsNoMatchError(err) {
+		return m.fallback.RESTMappings(gk, versions...)
+	}
+	return mappings, err
+}
+
+// KindFor implements meta.RESTMapper
+func (m *fallbackRESTMapper) KindFor(resource schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	gvk, err := m.RESTMapper.KindFor(resource)
+	if meta.IsNoMatchError(err) {
+		return m.fallback.KindFor(resource)
+	}
+	return gvk, err
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+//------------------------------------------------------------------------------
+
+// HasStatusSubresource returns true if the resource of the given GVK serves a
+// status subresource (e.g. custom resources whose definition enables it), as
+// reported by the discovery api. Results are cached for the lifetime of the
+// resource.
+func (r *Resource) HasStatusSubresource(gvk schema.GroupVersionKind) (bool, error) {
+	r.mut.Lock()
+	has, ok := r.statusSubresources[gvk]
+	r.mut.Unlock()
+	if ok {
+		return has, nil
+	}
+
+	mapper, err := r.Mapper()
+	if err != nil {
+		return false, err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, fmt.Errorf("error mapping resource: %v", err)
+	}
+	d, err := r.Discovery()
+	if err != nil {
+		return false, err
+	}
+	list, err := d.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if err != nil {
+		return false, fmt.Errorf("error discovering resources: %v", err)
+	}
+	status := mapping.Resource.Resource + "/status"
+	for _, res := range list.APIResources {
+		if res.Name == status {
+			has = true
+			break
+		}
+	}
+
+	r.mut.Lock()
+	if r.statusSubresources == nil {
+		r.statusSubresources = map[schema.GroupVersionKind]bool{}
+	}
+	r.statusSubresources[gvk] = has
+	r.mut.Unlock()
+	return has, nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// modulePath is the import path of this module, used to resolve its version
+// from the build info of the binary
+const modulePath = "github.com/cludden/benthos-kubernetes"
+
+// Version is the version reported in the default user agent, which may be set
+// at build time via -ldflags "-X github.com/cludden/benthos-kubernetes/client.Version=..."
+// and otherwise defaults to the module version recorded in the build info
+var Version = ""
+
+// DefaultUserAgent returns the default user agent for the given plugin
+// component (e.g. input, output, processor), which identifies requests made by
+// this module in API server audit logs and API Priority and Fairness
+func DefaultUserAgent(component string) string {
+	return fmt.Sprintf("benthos-kubernetes/%s (%s)", version(), component)
+}
+
+// version returns the configured Version, falling back to the module version
+// recorded in the build info
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath && dep.Version != "" {
+				return dep.Version
+			}
+		}
+	}
+	return "dev"
+}
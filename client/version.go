@@ -0,0 +1,99 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+//------------------------------------------------------------------------------
+
+// ResolveGVK returns the given GVK if it is served by the API server. When the
+// version is not served and strict is false, the preferred version of the kind
+// within the same group is returned, or if the group does not serve the kind
+// at all, the preferred version of the only other group serving a kind of the
+// same name (e.g. extensions/v1beta1 Deployment -> apps/v1 Deployment).
+// Resolved GVKs are logged once, and cached for the lifetime of the resource.
+func (r *Resource) ResolveGVK(gvk schema.GroupVersionKind, strict bool) (schema.GroupVersionKind, error) {
+	mapper, err := r.Mapper()
+	if err != nil {
+		return gvk, err
+	}
+	_, err = mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err == nil || !meta.IsNoMatchError(err) {
+		return gvk, err
+	}
+	if strict {
+		return gvk, fmt.Errorf("%s is not served by the api server: %v", gvk.String(), err)
+	}
+
+	r.mut.Lock()
+	resolved, ok := r.resolved[gvk]
+	r.mut.Unlock()
+	if ok {
+		return resolved, nil
+	}
+
+	if mapping, merr := mapper.RESTMapping(gvk.GroupKind()); merr == nil {
+		resolved = mapping.GroupVersionKind
+	} else if resolved, err = r.resolveKind(gvk, err); err != nil {
+		return gvk, err
+	}
+
+	r.mut.Lock()
+	if r.resolved == nil {
+		r.resolved = map[schema.GroupVersionKind]schema.GroupVersionKind{}
+	}
+	r.resolved[gvk] = resolved
+	r.mut.Unlock()
+
+	r.log.Warnf("%s is not served by the api server, using %s instead", gvk.String(), resolved.String())
+	return resolved, nil
+}
+
+// resolveKind searches the preferred resources of all groups for the kind of
+// the given GVK, which must be served by exactly one group
+func (r *Resource) resolveKind(gvk schema.GroupVersionKind, cause error) (schema.GroupVersionKind, error) {
+	d, err := r.Discovery()
+	if err != nil {
+		return gvk, err
+	}
+	// partial discovery failures (e.g. unavailable aggregated apis) are
+	// tolerated, as the remaining groups are still searched
+	lists, err := d.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return gvk, fmt.Errorf("error discovering resources: %v", err)
+	}
+
+	var candidates []schema.GroupVersionKind
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, res := range list.APIResources {
+			// skip subresources, which share the kind of their parent
+			if res.Kind == gvk.Kind && !strings.Contains(res.Name, "/") {
+				candidates = append(candidates, gv.WithKind(res.Kind))
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return gvk, cause
+	case 1:
+		return candidates[0], nil
+	default:
+		served := make([]string, len(candidates))
+		for i, c := range candidates {
+			served[i] = c.GroupVersion().String()
+		}
+		sort.Strings(served)
+		return gvk, fmt.Errorf("%s is not served by the api server, and kind %s is served by multiple groups (%s)", gvk.String(), gvk.Kind, strings.Join(served, ", "))
+	}
+}
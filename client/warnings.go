@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/metrics"
+)
+
+//------------------------------------------------------------------------------
+
+// warningRoundTripper forwards API server warnings, returned via Warning
+// response headers (e.g. when using a deprecated API version), to the logger
+// and metrics. This mirrors the rest.WarningHandler introduced in later
+// versions of client-go.
+type warningRoundTripper struct {
+	rt http.RoundTripper
+
+	log       log.Modular
+	mWarnings metrics.StatCounter
+
+	mu     sync.Mutex
+	logged map[string]struct{}
+}
+
+func newWarningRoundTripper(rt http.RoundTripper, log log.Modular, stats metrics.Type) *warningRoundTripper {
+	return &warningRoundTripper{
+		rt:        rt,
+		log:       log,
+		mWarnings: stats.GetCounter("kubernetes.api_warnings"),
+		logged:    map[string]struct{}{},
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (w *warningRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := w.rt.RoundTrip(req)
+	if err != nil || res == nil {
+		return res, err
+	}
+	for _, header := range res.Header["Warning"] {
+		w.handle(parseWarning(header))
+	}
+	return res, err
+}
+
+// handle records a single warning, logging each distinct warning only once to
+// avoid flooding the logs with repeated deprecation notices
+func (w *warningRoundTripper) handle(msg string) {
+	w.mWarnings.Incr(1)
+
+	w.mu.Lock()
+	_, seen := w.logged[msg]
+	if !seen {
+		w.logged[msg] = struct{}{}
+	}
+	w.mu.Unlock()
+
+	if !seen {
+		w.log.Warnf("kubernetes api warning: %s", msg)
+	}
+}
+
+// parseWarning extracts the warning text from a Warning header value of the
+// form `299 - "text"`, optionally followed by a quoted date, returning the raw
+// value if it cannot be parsed
+func parseWarning(header string) string {
+	if !strings.HasPrefix(header, "299 ") {
+		return header
+	}
+	start := strings.Index(header, `"`)
+	if start < 0 {
+		return header
+	}
+	// find the closing quote of the text, skipping escaped characters
+	end := -1
+	for i := start + 1; i < len(header) && end < 0; i++ {
+		switch header[i] {
+		case '\\':
+			i++
+		case '"':
+			end = i
+		}
+	}
+	if end < 0 {
+		return header
+	}
+	text, err := strconv.Unquote(header[start : end+1])
+	if err != nil {
+		return header[start+1 : end]
+	}
+	return text
+}
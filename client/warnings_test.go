@@ -0,0 +1,60 @@
+package client
+
+import "testing"
+
+func TestParseWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{
+			name:     "deprecated api",
+			header:   `299 - "extensions/v1beta1 Ingress is deprecated in v1.14+, unavailable in v1.22+; use networking.k8s.io/v1 Ingress"`,
+			expected: "extensions/v1beta1 Ingress is deprecated in v1.14+, unavailable in v1.22+; use networking.k8s.io/v1 Ingress",
+		},
+		{
+			name:     "with date",
+			header:   `299 - "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+" "Tue, 15 Nov 1994 08:12:31 GMT"`,
+			expected: "policy/v1beta1 PodSecurityPolicy is deprecated in v1.21+",
+		},
+		{
+			name:     "escaped quotes",
+			header:   `299 - "unknown field \"spec.foo\""`,
+			expected: `unknown field "spec.foo"`,
+		},
+		{
+			name:     "agent",
+			header:   `299 kube-apiserver "metadata.finalizers: \"example.com\": prefer a domain-qualified finalizer name"`,
+			expected: `metadata.finalizers: "example.com": prefer a domain-qualified finalizer name`,
+		},
+		{
+			name:     "invalid escape",
+			header:   `299 - "invalid \q escape"`,
+			expected: `invalid \q escape`,
+		},
+		{
+			name:     "other code",
+			header:   `199 - "miscellaneous warning"`,
+			expected: `199 - "miscellaneous warning"`,
+		},
+		{
+			name:     "unquoted",
+			header:   `299 - deprecated`,
+			expected: `299 - deprecated`,
+		},
+		{
+			name:     "unterminated",
+			header:   `299 - "deprecated`,
+			expected: `299 - "deprecated`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if msg := parseWarning(test.header); msg != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package input
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	kclient "github.com/cludden/benthos-kubernetes/client"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+//------------------------------------------------------------------------------
+
+// accessCheck identifies a single permission required by the input
+type accessCheck struct {
+	namespace string
+	resource  schema.GroupResource
+	verb      string
+}
+
+func (c accessCheck) String() string {
+	scope := "cluster wide"
+	if c.namespace != "" {
+		scope = "in namespace " + c.namespace
+	}
+	return fmt.Sprintf("%s %s %s", c.verb, c.resource.String(), scope)
+}
+
+// validatePermissions verifies that the input is permitted to list and watch
+// all watched kinds using self subject access reviews, failing with the list
+// of missing permissions if any
+func (k *Kubernetes) validatePermissions(res *kclient.Resource) error {
+	checks, err := k.requiredAccess(res)
+	if err != nil {
+		return err
+	}
+	clientset, err := res.Clientset()
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, check := range checks {
+		review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(k.ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: check.namespace,
+					Verb:      check.verb,
+					Group:     check.resource.Group,
+					Resource:  check.resource.Resource,
+				},
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("error reviewing permission to %s: %v", check.String(), err)
+		}
+		if !review.Status.Allowed {
+			missing = append(missing, check.String())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing rbac permissions: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// requiredAccess returns the permissions required by the configured watches.
+// Informer caches list and watch objects in all namespaces regardless of the
+// namespaces of a watch, whereas list mode watches list each namespace.
+func (k *Kubernetes) requiredAccess(res *kclient.Resource) ([]accessCheck, error) {
+	mapper, err := res.Mapper()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[accessCheck]bool{}
+	var checks []accessCheck
+	add := func(gvk schema.GroupVersionKind, namespaces []string, verbs ...string) error {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("error mapping %s: %v", gvk.String(), err)
+		}
+		if len(namespaces) == 0 || mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			namespaces = []string{""}
+		}
+		for _, ns := range namespaces {
+			for _, verb := range verbs {
+				check := accessCheck{namespace: ns, resource: mapping.Resource.GroupResource(), verb: verb}
+				if !seen[check] {
+					seen[check] = true
+					checks = append(checks, check)
+				}
+			}
+		}
+		return nil
+	}
+
+	namespaceGVK := schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	for i := range k.watches {
+		w := &k.watches[i]
+		if k.listMode {
+			var namespaces []string
+			if w.NamespaceSelector == nil {
+				namespaces = w.Namespaces
+			} else if err := add(namespaceGVK, nil, "list"); err != nil {
+				return nil, err
+			}
+			if err := add(w.GVK(), namespaces, "list"); err != nil {
+				return nil, err
+			}
+			if len(w.NamespaceLabels) > 0 {
+				if err := add(namespaceGVK, nil, "get"); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if err := add(w.GVK(), nil, "list", "watch"); err != nil {
+			return nil, err
+		}
+		for _, owned := range w.Owns {
+			if err := add(owned.GVK(), nil, "list", "watch"); err != nil {
+				return nil, err
+			}
+		}
+		if w.NamespaceSelector != nil || len(w.NamespaceLabels) > 0 {
+			if err := add(namespaceGVK, nil, "list", "watch"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.SliceStable(checks, func(i, j int) bool {
+		return checks[i].String() < checks[j].String()
+	})
+	return checks, nil
+}
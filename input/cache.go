@@ -0,0 +1,416 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// defaultResync mirrors the controller-runtime default informer resync period
+const defaultResync = 10 * time.Hour
+
+// allNamespacesIndexPrefix mirrors the controller-runtime field index key used
+// for cluster wide lookups
+const allNamespacesIndexPrefix = "__all_namespaces"
+
+//------------------------------------------------------------------------------
+
+// shareCacheRunnable returns a runnable that registers the informer cache of
+// the manager with the shared client resource once it has synced, such that
+// other plugins never read from an empty cache during startup or following a
+// manager restart. The cache is unregistered once the manager stops.
+func (k *Kubernetes) shareCacheRunnable(mgr manager.Manager) manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		c := mgr.GetCache()
+		if !c.WaitForCacheSync(stop) {
+			return nil
+		}
+
+		var gvks []schema.GroupVersionKind
+		for i := range k.watches {
+			w := &k.watches[i]
+			if w.MetadataOnly {
+				continue
+			}
+			k.resource.RegisterCache(w.GVK(), c, w.Typed && mgr.GetScheme().Recognizes(w.GVK()))
+			gvks = append(gvks, w.GVK())
+		}
+
+		<-stop
+		for _, gvk := range gvks {
+			k.resource.UnregisterCache(gvk, c)
+		}
+		return nil
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// listFilter defines server side list and watch filters for a single GVK
+type listFilter struct {
+	FieldSelector string
+	LabelSelector string
+	MetadataOnly  bool
+}
+
+// newFilteredCacheFunc returns a cache constructor that serves the given GVKs
+// from informers whose list and watch requests are filtered on the server,
+// such that only matching objects are stored in memory, while all other GVKs
+// are served by the default controller-runtime cache
+func newFilteredCacheFunc(filters map[schema.GroupVersionKind]listFilter) cache.NewCacheFunc {
+	return func(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+		base, err := cache.New(config, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(filters) == 0 {
+			return base, nil
+		}
+
+		dc, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing dynamic client: %v", err)
+		}
+		mc, err := metadata.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing metadata client: %v", err)
+		}
+
+		resync := defaultResync
+		if opts.Resync != nil {
+			resync = *opts.Resync
+		}
+
+		c := &filteredCache{
+			Cache:     base,
+			scheme:    opts.Scheme,
+			informers: map[schema.GroupVersionKind]*filteredInformer{},
+		}
+		for gvk, filter := range filters {
+			mapping, err := opts.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				return nil, fmt.Errorf("error mapping %s: %v", gvk.String(), err)
+			}
+			lw := newDynamicListWatch(dc, mapping, opts.Namespace)
+			if filter.MetadataOnly {
+				lw = newMetadataListWatch(mc, mapping, opts.Namespace)
+			}
+			c.informers[gvk] = newFilteredInformer(lw, mapping, filter, resync)
+		}
+		return c, nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// filteredInformer is an unstructured informer with server side filters
+type filteredInformer struct {
+	toolscache.SharedIndexInformer
+	resource schema.GroupResource
+}
+
+// listWatchFuncs lists and watches the objects of a single resource
+type listWatchFuncs struct {
+	list  func(opts metav1.ListOptions) (runtime.Object, error)
+	watch func(opts metav1.ListOptions) (watch.Interface, error)
+}
+
+// newDynamicListWatch returns list and watch functions for full objects
+func newDynamicListWatch(dc dynamic.Interface, mapping *meta.RESTMapping, namespace string) listWatchFuncs {
+	var ri dynamic.ResourceInterface = dc.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ri = dc.Resource(mapping.Resource).Namespace(namespace)
+	}
+	return listWatchFuncs{
+		list: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return ri.List(context.Background(), opts)
+		},
+		watch: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return ri.Watch(context.Background(), opts)
+		},
+	}
+}
+
+// newMetadataListWatch returns list and watch functions that retrieve only the
+// metadata of objects, which are converted to unstructured objects containing
+// the apiVersion, kind, and metadata of the object
+func newMetadataListWatch(mc metadata.Interface, mapping *meta.RESTMapping, namespace string) listWatchFuncs {
+	var ri metadata.ResourceInterface = mc.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ri = mc.Resource(mapping.Resource).Namespace(namespace)
+	}
+	gvk := mapping.GroupVersionKind
+	return listWatchFuncs{
+		list: func(opts metav1.ListOptions) (runtime.Object, error) {
+			list, err := ri.List(context.Background(), opts)
+			if err != nil {
+				return nil, err
+			}
+			result := &unstructured.UnstructuredList{}
+			result.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			result.SetResourceVersion(list.GetResourceVersion())
+			result.SetContinue(list.GetContinue())
+			for i := range list.Items {
+				u, err := metadataToUnstructured(&list.Items[i], gvk)
+				if err != nil {
+					return nil, err
+				}
+				result.Items = append(result.Items, *u)
+			}
+			return result, nil
+		},
+		watch: func(opts metav1.ListOptions) (watch.Interface, error) {
+			w, err := ri.Watch(context.Background(), opts)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				if pom, ok := e.Object.(*metav1.PartialObjectMetadata); ok {
+					u, err := metadataToUnstructured(pom, gvk)
+					if err != nil {
+						return watch.Event{Type: watch.Error, Object: &apierrors.NewInternalError(err).ErrStatus}, true
+					}
+					e.Object = u
+				}
+				return e, true
+			}), nil
+		},
+	}
+}
+
+// metadataToUnstructured converts partial object metadata to an unstructured
+// object of the given GVK
+func metadataToUnstructured(pom *metav1.PartialObjectMetadata, gvk schema.GroupVersionKind) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pom.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"metadata": content}}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+func newFilteredInformer(lw listWatchFuncs, mapping *meta.RESTMapping, filter listFilter, resync time.Duration) *filteredInformer {
+	tweak := func(opts *metav1.ListOptions) {
+		opts.LabelSelector = filter.LabelSelector
+		opts.FieldSelector = filter.FieldSelector
+	}
+
+	// the expected GVK identifies the informer in list and watch errors
+	expected := &unstructured.Unstructured{}
+	expected.SetGroupVersionKind(mapping.GroupVersionKind)
+
+	inf := toolscache.NewSharedIndexInformer(
+		&toolscache.ListWatch{
+			ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+				tweak(&opts)
+				return lw.list(opts)
+			},
+			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+				tweak(&opts)
+				return lw.watch(opts)
+			},
+		},
+		expected,
+		resync,
+		toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc},
+	)
+	return &filteredInformer{
+		SharedIndexInformer: inf,
+		resource:            mapping.Resource.GroupResource(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// filteredCache wraps a controller-runtime cache, serving specific GVKs from
+// server side filtered informers
+type filteredCache struct {
+	cache.Cache
+	scheme    *runtime.Scheme
+	informers map[schema.GroupVersionKind]*filteredInformer
+}
+
+// lookup returns the filtered informer for the given object, if any
+func (c *filteredCache) lookup(obj runtime.Object) (*filteredInformer, bool) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return nil, false
+	}
+	if meta.IsListType(obj) {
+		gvk.Kind = gvk.Kind[:len(gvk.Kind)-len("List")]
+	}
+	inf, ok := c.informers[gvk]
+	return inf, ok
+}
+
+// Get implements client.Reader
+func (c *filteredCache) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	inf, ok := c.lookup(obj)
+	if !ok {
+		return c.Cache.Get(ctx, key, obj)
+	}
+
+	storeKey := key.Name
+	if key.Namespace != "" {
+		storeKey = key.Namespace + "/" + key.Name
+	}
+	item, exists, err := inf.GetIndexer().GetByKey(storeKey)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apierrors.NewNotFound(inf.resource, key.Name)
+	}
+	return copyInto(item.(*unstructured.Unstructured), obj)
+}
+
+// List implements client.Reader
+func (c *filteredCache) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	inf, ok := c.lookup(list)
+	if !ok {
+		return c.Cache.List(ctx, list, opts...)
+	}
+
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	var items []interface{}
+	var err error
+	switch {
+	case listOpts.FieldSelector != nil && !listOpts.FieldSelector.Empty():
+		field, val, ok := requiresExactMatch(listOpts.FieldSelector)
+		if !ok {
+			return fmt.Errorf("non-exact field matches are not supported by the cache")
+		}
+		ns := listOpts.Namespace
+		if ns == "" {
+			ns = allNamespacesIndexPrefix
+		}
+		items, err = inf.GetIndexer().ByIndex("field:"+field, ns+"/"+val)
+	case listOpts.Namespace != "":
+		items, err = inf.GetIndexer().ByIndex(toolscache.NamespaceIndex, listOpts.Namespace)
+	default:
+		items = inf.GetIndexer().List()
+	}
+	if err != nil {
+		return err
+	}
+
+	result := &unstructured.UnstructuredList{}
+	for _, item := range items {
+		u := item.(*unstructured.Unstructured)
+		if listOpts.LabelSelector != nil && !listOpts.LabelSelector.Matches(labels.Set(u.GetLabels())) {
+			continue
+		}
+		result.Items = append(result.Items, *u.DeepCopy())
+	}
+
+	if ul, ok := list.(*unstructured.UnstructuredList); ok {
+		ul.Items = result.Items
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(result.UnstructuredContent(), list)
+}
+
+// GetInformer implements cache.Informers
+func (c *filteredCache) GetInformer(ctx context.Context, obj runtime.Object) (cache.Informer, error) {
+	if inf, ok := c.lookup(obj); ok {
+		return inf, nil
+	}
+	return c.Cache.GetInformer(ctx, obj)
+}
+
+// GetInformerForKind implements cache.Informers
+func (c *filteredCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (cache.Informer, error) {
+	if inf, ok := c.informers[gvk]; ok {
+		return inf, nil
+	}
+	return c.Cache.GetInformerForKind(ctx, gvk)
+}
+
+// Start implements cache.Informers
+func (c *filteredCache) Start(stop <-chan struct{}) error {
+	for _, inf := range c.informers {
+		go inf.Run(stop)
+	}
+	return c.Cache.Start(stop)
+}
+
+// WaitForCacheSync implements cache.Informers
+func (c *filteredCache) WaitForCacheSync(stop <-chan struct{}) bool {
+	synced := make([]toolscache.InformerSynced, 0, len(c.informers))
+	for _, inf := range c.informers {
+		synced = append(synced, inf.HasSynced)
+	}
+	if !toolscache.WaitForCacheSync(stop, synced...) {
+		return false
+	}
+	return c.Cache.WaitForCacheSync(stop)
+}
+
+// IndexField implements client.FieldIndexer
+func (c *filteredCache) IndexField(ctx context.Context, obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	inf, ok := c.lookup(obj)
+	if !ok {
+		return c.Cache.IndexField(ctx, obj, field, extractValue)
+	}
+	return inf.AddIndexers(toolscache.Indexers{
+		"field:" + field: func(raw interface{}) ([]string, error) {
+			u := raw.(*unstructured.Unstructured)
+			var vals []string
+			for _, v := range extractValue(u) {
+				vals = append(vals, allNamespacesIndexPrefix+"/"+v)
+				if ns := u.GetNamespace(); ns != "" {
+					vals = append(vals, ns+"/"+v)
+				}
+			}
+			return vals, nil
+		},
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// copyInto copies an unstructured object into the given object, converting
+// to a typed object if necessary
+func copyInto(u *unstructured.Unstructured, obj runtime.Object) error {
+	if out, ok := obj.(*unstructured.Unstructured); ok {
+		u.DeepCopyInto(out)
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.DeepCopy().Object, obj)
+}
+
+// requiresExactMatch returns the field and value of a field selector if it
+// consists of a single equality requirement
+func requiresExactMatch(sel fields.Selector) (string, string, bool) {
+	reqs := sel.Requirements()
+	if len(reqs) != 1 {
+		return "", "", false
+	}
+	req := reqs[0]
+	if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
+		return "", "", false
+	}
+	return req.Field, req.Value, true
+}
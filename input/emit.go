@@ -0,0 +1,74 @@
+package input
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Supported watch emit values
+const (
+	EmitOwner   = "owner"
+	EmitTrigger = "trigger"
+)
+
+//------------------------------------------------------------------------------
+
+// expandTriggerWatches replaces the owned dependencies of watches that emit
+// the triggering object with derived watches of the owned kinds, which admit
+// only objects owned by the watched kind. The derived watches are appended to
+// the given list of watches.
+func expandTriggerWatches(watches []Watch) ([]Watch, error) {
+	n := len(watches)
+	for i := 0; i < n; i++ {
+		switch watches[i].Emit {
+		case "", EmitOwner:
+			continue
+		case EmitTrigger:
+		default:
+			return nil, fmt.Errorf("invalid emit: %s", watches[i].Emit)
+		}
+		if len(watches[i].Owns) == 0 {
+			return nil, errors.New("emit trigger requires owns")
+		}
+		if watches[i].Mode == WatchModeList {
+			return nil, errors.New("emit trigger is not supported by list mode watches")
+		}
+		for _, dep := range watches[i].Owns {
+			watches = append(watches, watches[i].triggerWatch(dep))
+		}
+		watches[i].Owns = nil
+	}
+	return watches, nil
+}
+
+// triggerWatch returns a watch of an owned dependency, which inherits the
+// output and namespace scoping of the owner watch. Like the owned dependencies
+// of a watch, every change to an object controlled by the owner kind is
+// reconciled, whereas objects that merely reference the owner kind are not.
+// The filters of the owner watch apply to owner objects, and are therefore not
+// applied to owned objects.
+func (w *Watch) triggerWatch(dep ownerReference) Watch {
+	owner := w.GVK()
+	return Watch{
+		ownerReference:             dep,
+		BodyPath:                   w.BodyPath,
+		CoalesceWindow:             w.CoalesceWindow,
+		CollectResults:             w.CollectResults,
+		DisableGenerationPredicate: true,
+		FetchObject:                w.FetchObject,
+		Mapping:                    w.Mapping,
+		MaxConcurrentReconciles:    w.MaxConcurrentReconciles,
+		NamespaceLabels:            w.NamespaceLabels,
+		NamespaceSelector:          w.NamespaceSelector,
+		Namespaces:                 w.Namespaces,
+		OwnedBy:                    owner.GroupVersion().String() + "/" + owner.Kind,
+		Pretty:                     w.Pretty,
+		Sharding:                   w.Sharding,
+		StrictVersion:              w.StrictVersion,
+		StripManagedFields:         w.StripManagedFields,
+		StripStatus:                w.StripStatus,
+		Typed:                      w.Typed,
+
+		controlledBy: true,
+	}
+}
@@ -0,0 +1,74 @@
+package input
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	bmeta "github.com/Jeffail/benthos/v3/lib/message/metadata"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// HeartbeatMetadataKey identifies heartbeat messages, and contains the time at
+// which the heartbeat was emitted
+const HeartbeatMetadataKey = "benthos_kubernetes_heartbeat"
+
+//------------------------------------------------------------------------------
+
+// heartbeatRunnable returns a runnable that periodically emits a heartbeat
+// message while the controller manager is running, regardless of whether any
+// watched objects change
+func (k *Kubernetes) heartbeatRunnable() manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(k.heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return nil
+			}
+			if !k.emitHeartbeat(stop) {
+				return nil
+			}
+		}
+	})
+}
+
+// emitHeartbeat sends a single heartbeat message downstream and waits for it
+// to be acknowledged, returning false if the input is closing. Heartbeats that
+// fail are logged rather than retried, given that a subsequent heartbeat
+// supersedes them.
+func (k *Kubernetes) emitHeartbeat(stop <-chan struct{}) bool {
+	// heartbeats are tracked as in-flight transactions, such that they are
+	// never sent once the input begins closing
+	if !k.admit() {
+		return false
+	}
+	defer k.inFlight.Done()
+
+	part := message.NewPart(nil)
+	part.SetMetadata(bmeta.New(map[string]string{
+		HeartbeatMetadataKey: time.Now().UTC().Format(time.RFC3339Nano),
+	}))
+	msg := message.New(nil)
+	msg.Append(part)
+
+	resChan := make(chan types.Response, 1)
+	select {
+	case k.transactionsChan <- types.NewTransaction(msg, resChan):
+	case <-stop:
+		return false
+	}
+	k.mHeartbeats.Incr(1)
+
+	select {
+	case res := <-resChan:
+		if err := res.Error(); err != nil {
+			k.log.Errorf("heartbeat not acknowledged: %v", err)
+		}
+	case <-k.abandonChan:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+//------------------------------------------------------------------------------
+
+// registerIndexes registers the index_fields of all watches with the cache of
+// the given manager, which must be called prior to starting the manager
+func (k *Kubernetes) registerIndexes(mgr manager.Manager) error {
+	indexed := map[schema.GroupVersionKind]map[string]bool{}
+	for i := range k.watches {
+		w := &k.watches[i]
+		gvk := w.GVK()
+		if indexed[gvk] == nil {
+			indexed[gvk] = map[string]bool{}
+		}
+		for _, field := range w.IndexFields {
+			// watches of the same kind share an informer, and therefore its
+			// indexes
+			if indexed[gvk][field] {
+				continue
+			}
+			if err := mgr.GetFieldIndexer().IndexField(k.ctx, w.NewObject(mgr.GetScheme()), field, indexFieldFunc(field)); err != nil {
+				return fmt.Errorf("error indexing %s of %s: %v", field, gvk.String(), err)
+			}
+			indexed[gvk][field] = true
+		}
+	}
+	return nil
+}
+
+// indexFieldFunc returns an indexer that extracts the scalar value, or list of
+// scalar values, at the given dot separated path of an object
+func indexFieldFunc(field string) client.IndexerFunc {
+	path := strings.Split(field, ".")
+	return func(obj runtime.Object) []string {
+		var content map[string]interface{}
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			content = u.Object
+		} else {
+			var err error
+			if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+				return nil
+			}
+		}
+
+		v, ok, err := unstructured.NestedFieldNoCopy(content, path...)
+		if err != nil || !ok || v == nil {
+			return nil
+		}
+		switch value := v.(type) {
+		case []interface{}:
+			var values []string
+			for _, item := range value {
+				if s, ok := indexValue(item); ok {
+					values = append(values, s)
+				}
+			}
+			return values
+		default:
+			if s, ok := indexValue(value); ok {
+				return []string{s}
+			}
+			return nil
+		}
+	}
+}
+
+// indexValue formats a scalar value as an index value
+func indexValue(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case string:
+		return value, true
+	case bool, int64, float64:
+		return fmt.Sprint(value), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,106 @@
+package input
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+//------------------------------------------------------------------------------
+
+// initialListFilter suppresses the create events of objects returned by the
+// initial list of an informer. Create events received before the informer has
+// synced are dropped, and as the informer delivers events asynchronously, the
+// resource versions of the objects in the synced cache are recorded such that
+// create events for those same object versions delivered after the sync are
+// also dropped.
+type initialListFilter struct {
+	mu       sync.Mutex
+	informer cache.Informer
+	list     func() ([]runtime.Object, error)
+	initial  map[string]string
+}
+
+// newInitialListFilter returns a predicate that suppresses the initial list of
+// the informer of a watch, which must be called prior to starting the manager
+func (k *Kubernetes) newInitialListFilter(mgr manager.Manager, w *Watch) (predicate.Predicate, error) {
+	informer, err := mgr.GetCache().GetInformer(k.ctx, w.NewObject(mgr.GetScheme()))
+	if err != nil {
+		return nil, err
+	}
+	f := &initialListFilter{
+		informer: informer,
+		list: func() ([]runtime.Object, error) {
+			return k.cachedObjects(mgr, w)
+		},
+	}
+	return predicate.Funcs{
+		CreateFunc: f.Create,
+	}, nil
+}
+
+// Create returns false for create events of objects in the initial list
+func (f *initialListFilter) Create(e event.CreateEvent) bool {
+	if !f.informer.HasSynced() {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.initial == nil {
+		f.initial = map[string]string{}
+		items, err := f.list()
+		if err != nil {
+			predicateLog.Error(err, "error listing initial objects")
+		}
+		for _, item := range items {
+			if m, err := meta.Accessor(item); err == nil {
+				f.initial[m.GetNamespace()+"/"+m.GetName()] = m.GetResourceVersion()
+			}
+		}
+	}
+
+	key := e.Meta.GetNamespace() + "/" + e.Meta.GetName()
+	rv, ok := f.initial[key]
+	if !ok {
+		return true
+	}
+	delete(f.initial, key)
+	return rv != e.Meta.GetResourceVersion()
+}
+
+//------------------------------------------------------------------------------
+
+// cachedObjects returns the objects in the cache of a watch matching the given
+// list options, waiting for the cache to sync if the manager has been started
+func (k *Kubernetes) cachedObjects(mgr manager.Manager, w *Watch, opts ...client.ListOption) ([]runtime.Object, error) {
+	gvk := w.GVK()
+	scheme := mgr.GetScheme()
+
+	// the informer is shared with the controller, and blocks until synced
+	obj := w.NewObject(scheme)
+	if _, err := mgr.GetCache().GetInformer(k.ctx, obj); err != nil {
+		return nil, err
+	}
+
+	var list runtime.Object = &unstructured.UnstructuredList{}
+	list.GetObjectKind().SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if _, ok := obj.(*unstructured.Unstructured); !ok {
+		typed, err := scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err != nil {
+			return nil, err
+		}
+		list = typed
+	}
+	if err := mgr.GetCache().List(k.ctx, list, opts...); err != nil {
+		return nil, err
+	}
+	return meta.ExtractList(list)
+}
@@ -2,8 +2,11 @@ package input
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,19 +18,27 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	kclient "github.com/cludden/benthos-kubernetes/client"
 	klog "github.com/cludden/benthos-kubernetes/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 func init() {
@@ -57,21 +68,55 @@ This plugin streams changes to kubernetes objects from a given cluster.`,
 
 // KubernetesConfig defines runtime configuration for a kubernetes input
 type KubernetesConfig struct {
-	Result  KubernetesResultConfig `json:"result" yaml:"result"`
-	Watches []Watch                `json:"watches,omitempty" yaml:"watches,omitempty"`
+	kclient.Config      `json:",inline" yaml:",inline"`
+	Client              string                      `json:"client" yaml:"client"`
+	HeartbeatInterval   string                      `json:"heartbeat_interval" yaml:"heartbeat_interval"`
+	MaxInFlight         int                         `json:"max_in_flight" yaml:"max_in_flight"`
+	RateLimit           string                      `json:"rate_limit" yaml:"rate_limit"`
+	RequireNamespaces   bool                        `json:"require_namespaces" yaml:"require_namespaces"`
+	ReconcileTimeout    string                      `json:"reconcile_timeout" yaml:"reconcile_timeout"`
+	Restart             KubernetesRestartConfig     `json:"restart" yaml:"restart"`
+	Result              KubernetesResultConfig      `json:"result" yaml:"result"`
+	ShutdownTimeout     string                      `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	SyncMarker          bool                        `json:"sync_marker" yaml:"sync_marker"`
+	ValidatePermissions bool                        `json:"validate_permissions" yaml:"validate_permissions"`
+	WatchErrors         KubernetesWatchErrorsConfig `json:"watch_errors" yaml:"watch_errors"`
+	Watches             []Watch                     `json:"watches,omitempty" yaml:"watches,omitempty"`
 }
 
 // NewKubernetesConfig creates a new KubernetesConfig with default values
 func NewKubernetesConfig() *KubernetesConfig {
 	return &KubernetesConfig{
-		Result: NewKubernetesResultConfig(),
+		Config:          kclient.NewConfig(),
+		Restart:         NewKubernetesRestartConfig(),
+		Result:          NewKubernetesResultConfig(),
+		ShutdownTimeout: "5s",
+		WatchErrors:     NewKubernetesWatchErrorsConfig(),
+	}
+}
+
+// KubernetesRestartConfig provides config fields for customizing the restart
+// behavior when the controller manager fails to start
+type KubernetesRestartConfig struct {
+	InitialInterval string `json:"initial_interval" yaml:"initial_interval"`
+	MaxAttempts     int    `json:"max_attempts" yaml:"max_attempts"`
+	MaxInterval     string `json:"max_interval" yaml:"max_interval"`
+}
+
+// NewKubernetesRestartConfig returns a KubernetesRestartConfig with default values
+func NewKubernetesRestartConfig() KubernetesRestartConfig {
+	return KubernetesRestartConfig{
+		InitialInterval: "1s",
+		MaxAttempts:     10,
+		MaxInterval:     "30s",
 	}
 }
 
 // KubernetesResultConfig provides config fields for customing the result
 type KubernetesResultConfig struct {
-	Requeue      string `json:"requeue" yaml:"requeue"`
-	RequeueAfter string `json:"requeue_after" yaml:"requeue_after"`
+	Requeue            string  `json:"requeue" yaml:"requeue"`
+	RequeueAfter       string  `json:"requeue_after" yaml:"requeue_after"`
+	RequeueAfterJitter float64 `json:"requeue_after_jitter" yaml:"requeue_after_jitter"`
 }
 
 // NewKubernetesResultConfig returns a KubernetesResultConfig with default values
@@ -82,21 +127,138 @@ func NewKubernetesResultConfig() KubernetesResultConfig {
 // Watch defines a controller configuration
 type Watch struct {
 	ownerReference             `json:",inline" yaml:",inline"`
+	BodyPath                   string           `json:"body_path" yaml:"body_path"`
+	ChangedFields              []string         `json:"changed_fields,omitempty" yaml:"changed_fields,omitempty"`
+	CoalesceWindow             string           `json:"coalesce_window" yaml:"coalesce_window"`
+	CollectResults             *bool            `json:"collect_results,omitempty" yaml:"collect_results,omitempty"`
 	DisableGenerationPredicate bool             `json:"disable_generation_predicate" yaml:"disable_generation_predicate"`
+	DisableSelectorPushdown    bool             `json:"disable_selector_pushdown" yaml:"disable_selector_pushdown"`
+	Emit                       string           `json:"emit" yaml:"emit"`
+	FetchObject                *bool            `json:"fetch_object,omitempty" yaml:"fetch_object,omitempty"`
+	FieldSelector              string           `json:"field_selector" yaml:"field_selector"`
+	IncludeCountUpdates        bool             `json:"include_count_updates" yaml:"include_count_updates"`
+	IncludePrevious            bool             `json:"include_previous" yaml:"include_previous"`
+	IndexFields                []string         `json:"index_fields,omitempty" yaml:"index_fields,omitempty"`
+	Mapping                    string           `json:"mapping" yaml:"mapping"`
+	MaxConcurrentReconciles    int              `json:"max_concurrent_reconciles" yaml:"max_concurrent_reconciles"`
+	MetadataOnly               bool             `json:"metadata_only" yaml:"metadata_only"`
+	Mode                       string           `json:"mode" yaml:"mode"`
+	Name                       string           `json:"name" yaml:"name"`
+	NamespaceLabels            []string         `json:"namespace_labels,omitempty" yaml:"namespace_labels,omitempty"`
+	NamespaceSelector          *selector        `json:"namespace_selector,omitempty" yaml:"namespace_selector,omitempty"`
 	Namespaces                 []string         `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	OwnedBy                    string           `json:"owned_by" yaml:"owned_by"`
 	Owns                       []ownerReference `json:"owns,omitempty" yaml:"owns,omitempty"`
+	Predicate                  string           `json:"predicate" yaml:"predicate"`
+	Predicates                 []string         `json:"predicates,omitempty" yaml:"predicates,omitempty"`
+	Pretty                     bool             `json:"pretty" yaml:"pretty"`
+	RelistInterval             string           `json:"relist_interval" yaml:"relist_interval"`
 	Selector                   *selector        `json:"selector,omitempty" yaml:"selector,omitempty"`
+	Sharding                   *sharding        `json:"sharding,omitempty" yaml:"sharding,omitempty"`
+	SkipInitialList            bool             `json:"skip_initial_list" yaml:"skip_initial_list"`
+	StrictVersion              bool             `json:"strict_version" yaml:"strict_version"`
+	StripManagedFields         bool             `json:"strip_managed_fields" yaml:"strip_managed_fields"`
+	StripStatus                bool             `json:"strip_status" yaml:"strip_status"`
+	Typed                      bool             `json:"typed" yaml:"typed"`
+	WatchStatusOnly            bool             `json:"watch_status_only" yaml:"watch_status_only"`
+
+	coalesceWindow time.Duration
+	controlledBy   bool
+	mapping        bloblang.Mapping
+	name           string
+	namespaces     *namespaceMatcher
+	nsLabels       *namespaceLabels
+	relist         chan event.GenericEvent
+	relistInterval time.Duration
 }
 
+// errObjectDropped indicates that the mapping of a watch deleted an object,
+// such that no message is emitted
+var errObjectDropped = errors.New("object dropped by mapping")
+
 // Options returns a list of watch predicates using runtime config
 func (w *Watch) Options() ([]builder.ForOption, error) {
 	var opts []builder.ForOption
 
-	// include generation changed predicate unless explicitly disabled
-	if w.DisableGenerationPredicate != true {
+	// include change predicates, defaulting to generation changes unless
+	// explicitly disabled
+	switch {
+	case w.WatchStatusOnly:
+		if len(w.Predicates) > 0 || len(w.ChangedFields) > 0 || w.DisableGenerationPredicate {
+			return nil, errors.New("watch_status_only cannot be used with predicates, changed_fields, or disable_generation_predicate")
+		}
+		p, err := newChangePredicate([]string{PredicateStatus}, nil)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, builder.WithPredicates(p))
+	case len(w.Predicates) > 0 || len(w.ChangedFields) > 0:
+		if w.DisableGenerationPredicate {
+			return nil, errors.New("predicates and changed_fields cannot be used with disable_generation_predicate")
+		}
+		p, err := newChangePredicate(w.Predicates, w.ChangedFields)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, builder.WithPredicates(p))
+	case w.IsEvent():
+		// events do not have a generation, such that the default generation
+		// predicate would ignore all updates
+	case !w.DisableGenerationPredicate:
 		opts = append(opts, builder.WithPredicates(predicate.GenerationChangedPredicate{}))
 	}
 
+	// collapse repeated events into a single message per logical event
+	switch {
+	case w.IsEvent() && !w.IncludeCountUpdates:
+		opts = append(opts, builder.WithPredicates(newEventCountPredicate()))
+	case !w.IsEvent() && w.IncludeCountUpdates:
+		return nil, errors.New("include_count_updates is only supported for Event watches")
+	}
+
+	filters, err := w.FilterPredicates()
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) > 0 {
+		opts = append(opts, builder.WithPredicates(filters...))
+	}
+
+	return opts, nil
+}
+
+// ShouldFetch returns true if reconciled objects should be read from the
+// cache, which is the default when fetch_object is unset
+func (w *Watch) ShouldFetch() bool {
+	return w.FetchObject == nil || *w.FetchObject
+}
+
+// ShouldCollectResults returns true if the results of reconcile transactions
+// should be collected to determine whether objects are requeued, which is the
+// default when collect_results is unset
+func (w *Watch) ShouldCollectResults() bool {
+	return w.CollectResults == nil || *w.CollectResults
+}
+
+// IsEvent returns true if the watched kind is a core/v1 or events.k8s.io Event
+func (w *Watch) IsEvent() bool {
+	return w.Kind == "Event" && (w.Group == "" || w.Group == "events.k8s.io")
+}
+
+// FilterPredicates returns the predicates that restrict the set of objects
+// admitted by this watch (i.e. sharding, namespaces, owned_by, selector, and
+// predicate)
+func (w *Watch) FilterPredicates() ([]predicate.Predicate, error) {
+	var preds []predicate.Predicate
+
+	// include sharding predicate if specified
+	if w.Sharding != nil {
+		if err := w.Sharding.validate(); err != nil {
+			return nil, err
+		}
+		preds = append(preds, w.Sharding.Predicate())
+	}
+
 	// include namespace filter predicate if specified
 	if len(w.Namespaces) > 0 {
 		namespaces := map[string]struct{}{}
@@ -109,7 +271,7 @@ func (w *Watch) Options() ([]builder.ForOption, error) {
 			return ok
 		}
 
-		opts = append(opts, builder.WithPredicates(predicate.Funcs{
+		preds = append(preds, predicate.Funcs{
 			CreateFunc: func(e event.CreateEvent) bool {
 				return matchesNamespace(e.Meta.GetNamespace())
 			},
@@ -122,71 +284,303 @@ func (w *Watch) Options() ([]builder.ForOption, error) {
 			UpdateFunc: func(e event.UpdateEvent) bool {
 				return matchesNamespace(e.MetaNew.GetNamespace())
 			},
-		}))
+		})
+	}
+
+	// include owner reference predicate if specified
+	if w.OwnedBy != "" {
+		p, err := newOwnedByPredicate(w.OwnedBy, w.controlledBy)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, p)
 	}
 
 	// include label selector predicate if specified
-	if w.Selector != nil {
-		selector := metav1.LabelSelector{
-			MatchLabels: w.Selector.MatchLabels,
+	selector, err := w.LabelSelector()
+	if err != nil {
+		return nil, err
+	}
+	if selector != nil {
+		preds = append(preds, predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return selector.Matches(labels.Set(e.Meta.GetLabels()))
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return selector.Matches(labels.Set(e.Meta.GetLabels()))
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return selector.Matches(labels.Set(e.Meta.GetLabels()))
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return selector.Matches(labels.Set(e.MetaNew.GetLabels()))
+			},
+		})
+	}
+
+	// include custom bloblang predicate if specified
+	if w.Predicate != "" {
+		p, err := newMappingPredicate(w.Predicate)
+		if err != nil {
+			return nil, err
 		}
+		preds = append(preds, p)
+	}
+
+	return preds, nil
+}
 
-		for i := 0; i < len(w.Selector.MatchExpressions); i++ {
-			expr := w.Selector.MatchExpressions[i]
-			selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
-				Key:      expr.Key,
-				Operator: expr.Operator,
-				Values:   expr.Values,
-			})
+// LabelSelector returns the parsed label selector, or nil if not specified
+func (w *Watch) LabelSelector() (labels.Selector, error) {
+	parsed, err := w.Selector.parse()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing selector: %v", err)
+	}
+	return parsed, nil
+}
+
+// NamespaceLabelSelector returns the parsed namespace label selector, or nil if
+// not specified
+func (w *Watch) NamespaceLabelSelector() (labels.Selector, error) {
+	parsed, err := w.NamespaceSelector.parse()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing namespace_selector: %v", err)
+	}
+	return parsed, nil
+}
+
+// ListFilter returns the server side list filter for this watch, and false if
+// no filtering should be pushed down to the cache (i.e. neither selectors nor
+// metadata only informers)
+func (w *Watch) ListFilter() (listFilter, bool, error) {
+	if w.DisableSelectorPushdown {
+		if w.FieldSelector != "" {
+			return listFilter{}, false, errors.New("field_selector cannot be used with disable_selector_pushdown")
 		}
+		return listFilter{MetadataOnly: w.MetadataOnly}, w.MetadataOnly, nil
+	}
 
-		if selector.Size() > 0 {
-			selector, err := metav1.LabelSelectorAsSelector(&selector)
-			if err != nil {
-				return nil, fmt.Errorf("error parsing selector: %v", err)
+	filter := listFilter{MetadataOnly: w.MetadataOnly}
+	selector, err := w.LabelSelector()
+	if err != nil {
+		return filter, false, err
+	}
+	if selector != nil {
+		filter.LabelSelector = selector.String()
+	}
+	if w.FieldSelector != "" {
+		fieldSelector, err := fields.ParseSelector(w.FieldSelector)
+		if err != nil {
+			return filter, false, fmt.Errorf("error parsing field_selector: %v", err)
+		}
+		filter.FieldSelector = fieldSelector.String()
+	}
+	return filter, filter.LabelSelector != "" || filter.FieldSelector != "" || filter.MetadataOnly, nil
+}
+
+// Marshal encodes an object as a message body, stripping any configured
+// fields and wrapping the object in an envelope if a body path is specified
+func (w *Watch) Marshal(obj runtime.Object, event string) ([]byte, error) {
+	body, err := w.encode(obj)
+	if err != nil {
+		return nil, err
+	}
+	return w.wrap(body, event)
+}
+
+// MarshalChange encodes the previous and current state of an object as a
+// message body of the form {"old": ..., "new": ...}, where either state may be
+// nil (e.g. for created and deleted objects). The message is dropped if the
+// mapping drops the current state, or the previous state of a deleted object.
+func (w *Watch) MarshalChange(old, obj runtime.Object, event string) ([]byte, error) {
+	change := map[string]interface{}{
+		"new": nil,
+		"old": nil,
+	}
+	if obj != nil {
+		body, err := w.encode(obj)
+		if err != nil {
+			return nil, err
+		}
+		change["new"] = body
+	}
+	if old != nil {
+		body, err := w.encode(old)
+		switch {
+		case err == errObjectDropped && obj != nil:
+		case err != nil:
+			return nil, err
+		default:
+			change["old"] = body
+		}
+	}
+	return w.wrap(change, event)
+}
+
+// encode strips any configured fields from an object and applies the mapping
+// of the watch, if any
+func (w *Watch) encode(obj runtime.Object) (interface{}, error) {
+	var body interface{} = obj
+	if w.StripManagedFields || w.StripStatus {
+		var content map[string]interface{}
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			content = u.UnstructuredContent()
+		} else {
+			var err error
+			if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+				return nil, err
 			}
+		}
+		if w.StripManagedFields {
+			unstructured.RemoveNestedField(content, "metadata", "managedFields")
+		}
+		if w.StripStatus {
+			unstructured.RemoveNestedField(content, "status")
+		}
+		body = content
+	}
 
-			opts = append(opts, builder.WithPredicates(predicate.Funcs{
-				CreateFunc: func(e event.CreateEvent) bool {
-					return selector.Matches(labels.Set(e.Meta.GetLabels()))
-				},
-				DeleteFunc: func(e event.DeleteEvent) bool {
-					return selector.Matches(labels.Set(e.Meta.GetLabels()))
-				},
-				GenericFunc: func(e event.GenericEvent) bool {
-					return selector.Matches(labels.Set(e.Meta.GetLabels()))
-				},
-				UpdateFunc: func(e event.UpdateEvent) bool {
-					return selector.Matches(labels.Set(e.MetaNew.GetLabels()))
-				},
-			}))
+	if w.mapping != nil {
+		return w.transform(body)
+	}
+	return body, nil
+}
+
+// wrap wraps an encoded body in an envelope if a body path is specified, and
+// marshals the result
+func (w *Watch) wrap(body interface{}, event string) ([]byte, error) {
+	if w.BodyPath != "" {
+		body = map[string]interface{}{
+			w.BodyPath: body,
+			"event":    event,
 		}
 	}
 
-	return opts, nil
+	if w.Pretty {
+		return json.MarshalIndent(body, "", "  ")
+	}
+	return json.Marshal(body)
 }
 
-// Register adds a new controller to the controller manager
-func (w *Watch) Register(mgr manager.Manager, r reconcile.Reconciler) error {
-	gvk := w.GVK()
-	u := &unstructured.Unstructured{}
-	u.SetGroupVersionKind(gvk)
+// transform applies the mapping of the watch to the given object, returning
+// errObjectDropped if the mapping deletes the object
+func (w *Watch) transform(body interface{}) (interface{}, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	msg := message.New([][]byte{b})
+	part, err := w.mapping.MapPart(0, msg)
+	if err != nil {
+		return nil, fmt.Errorf("error executing mapping: %v", err)
+	}
+	if part == nil {
+		return nil, errObjectDropped
+	}
+	var result interface{}
+	if err := json.Unmarshal(part.Get(), &result); err != nil {
+		// mappings that produce non-JSON documents are emitted as strings
+		return string(part.Get()), nil
+	}
+	return result, nil
+}
+
+// Register adds a new controller to the controller manager, including any
+// additional predicates provided
+func (w *Watch) Register(mgr manager.Manager, r reconcile.Reconciler, preds ...predicate.Predicate) error {
+	obj := w.NewObject(mgr.GetScheme())
 
 	opts, err := w.Options()
 	if err != nil {
 		return fmt.Errorf("error building controller options: %v", err)
 	}
+	if len(preds) > 0 {
+		opts = append(opts, builder.WithPredicates(preds...))
+	}
 
-	bldr := builder.ControllerManagedBy(mgr).For(u, opts...)
+	bldr := builder.ControllerManagedBy(mgr).Named(w.name).For(obj, opts...)
+	if w.namespaces != nil {
+		bldr = bldr.Watches(
+			&source.Kind{Type: &corev1.Namespace{}},
+			w.namespaces.handler(),
+			builder.WithPredicates(w.namespaces.transitionPredicate()),
+		)
+	}
+	// relisted objects bypass the change predicates of the watch, but are
+	// still restricted to the objects admitted by the watch
+	if w.relist != nil {
+		filters, err := w.FilterPredicates()
+		if err != nil {
+			return err
+		}
+		if w.namespaces != nil {
+			filters = append(filters, w.namespaces.Predicate())
+		}
+		bldr = bldr.Watches(
+			&source.Channel{Source: w.relist},
+			&handler.EnqueueRequestForObject{},
+			builder.WithPredicates(filters...),
+		)
+	}
 	for _, dep := range w.Owns {
 		owned := &unstructured.Unstructured{}
 		owned.SetGroupVersionKind(dep.GVK())
 		bldr = bldr.Owns(owned)
 	}
 
+	if w.MaxConcurrentReconciles > 0 {
+		bldr = bldr.WithOptions(controller.Options{
+			MaxConcurrentReconciles: w.MaxConcurrentReconciles,
+		})
+	}
+
 	return bldr.Complete(r)
 }
 
+// resolveVersions resolves the watched and owned GVKs to the versions served by
+// the API server, unless strict_version is set
+func (w *Watch) resolveVersions(res *kclient.Resource) error {
+	gvk, err := res.ResolveGVK(w.GVK(), w.StrictVersion)
+	if err != nil {
+		return fmt.Errorf("error resolving watch version: %v", err)
+	}
+	w.Group, w.Version, w.Kind = gvk.Group, gvk.Version, gvk.Kind
+	for i := range w.Owns {
+		gvk, err := res.ResolveGVK(w.Owns[i].GVK(), w.StrictVersion)
+		if err != nil {
+			return fmt.Errorf("error resolving owned version: %v", err)
+		}
+		w.Owns[i].Group, w.Owns[i].Version, w.Owns[i].Kind = gvk.Group, gvk.Version, gvk.Kind
+	}
+	return nil
+}
+
+// defaultName returns a human readable controller name derived from the
+// watched GVK (e.g. deployment-v1-apps)
+func (w *Watch) defaultName() string {
+	parts := []string{strings.ToLower(w.Kind), strings.ToLower(w.Version)}
+	if w.Group != "" {
+		parts = append(parts, strings.Replace(strings.ToLower(w.Group), ".", "-", -1))
+	}
+	return strings.Join(parts, "-")
+}
+
+// NewObject returns an empty object for the watched GVK, which is a typed
+// object if enabled and the GVK is registered in the given scheme, or an
+// unstructured object otherwise
+func (w *Watch) NewObject(scheme *runtime.Scheme) runtime.Object {
+	gvk := w.GVK()
+	if w.Typed {
+		if obj, err := scheme.New(gvk); err == nil {
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+			return obj
+		}
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	return u
+}
+
 type ownerReference struct {
 	Group   string `json:"group" yaml:"group"`
 	Kind    string `json:"kind" yaml:"kind"`
@@ -207,6 +601,29 @@ type selector struct {
 	MatchExpressions []selectorRequirement `json:"matchExpressions,omitempty" yaml:"matchExpressions,omitempty"`
 }
 
+// parse returns the parsed label selector, or nil if empty
+func (s *selector) parse() (labels.Selector, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	selector := metav1.LabelSelector{
+		MatchLabels: s.MatchLabels,
+	}
+	for i := 0; i < len(s.MatchExpressions); i++ {
+		expr := s.MatchExpressions[i]
+		selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      expr.Key,
+			Operator: expr.Operator,
+			Values:   expr.Values,
+		})
+	}
+	if selector.Size() == 0 {
+		return nil, nil
+	}
+	return metav1.LabelSelectorAsSelector(&selector)
+}
+
 type selectorRequirement struct {
 	Key      string                       `json:"key" yaml:"key"`
 	Operator metav1.LabelSelectorOperator `json:"operator" yaml:"operator"`
@@ -217,20 +634,61 @@ type selectorRequirement struct {
 
 // Kubernetes input watches one or more k8s resources
 type Kubernetes struct {
-	mgr manager.Manager
+	mgr         manager.Manager
+	resource    *kclient.Resource
+	restConfig  *rest.Config
+	listFilters map[schema.GroupVersionKind]listFilter
+	listMode    bool
+	watches     []Watch
 
-	requeue      bloblang.Mapping
-	requeueAfter bloblang.Field
+	restartInitialInterval time.Duration
+	restartMaxAttempts     int
+	restartMaxInterval     time.Duration
 
-	resChan          chan types.Response
-	transactionsChan chan types.Transaction
+	requeue            bloblang.Mapping
+	requeueAfter       bloblang.Field
+	requeueAfterJitter float64
 
-	log   log.Modular
-	stats metrics.Type
+	events            *eventTracker
+	tombstones        *tombstoneTracker
+	previous          *previousTracker
+	objectLocks       *keyedMutex
+	coalescer         *coalescer
+	rateLimit         types.RateLimit
+	reconcileTimeout  time.Duration
+	inFlightSlots     chan struct{}
+	sync              *syncTracker
+	syncMarker        bool
+	heartbeatInterval time.Duration
+	emitWatchErrors   bool
+	transactionsChan  chan types.Transaction
 
-	closeOnce  sync.Once
-	closeChan  chan struct{}
-	closedChan chan struct{}
+	shutdownTimeout time.Duration
+	inFlight        sync.WaitGroup
+	inFlightMut     sync.Mutex
+	closing         bool
+
+	log          log.Modular
+	stats        metrics.Type
+	mAbandoned   metrics.StatCounter
+	mRestarts    metrics.StatCounter
+	mTimeouts    metrics.StatCounter
+	mCoalesced   metrics.StatCounter
+	mWatchErrors metrics.StatCounter
+	mLimited     metrics.StatCounter
+	mLimitFor    metrics.StatCounter
+	mLimitErr    metrics.StatCounter
+	mLatency     metrics.StatTimerVec
+	mRelisted    metrics.StatCounter
+	mHeartbeats  metrics.StatCounter
+	mErrors      *kclient.ErrorCounter
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	closeOnce   sync.Once
+	closeChan   chan struct{}
+	abandonChan chan struct{}
+	closedChan  chan struct{}
 }
 
 // NewKubernetes creates a new kubernetes input type
@@ -243,14 +701,99 @@ func NewKubernetes(
 	logf.SetLogger(klog.New(log))
 	// define input
 	c := &Kubernetes{
-		log:   log,
-		stats: stats,
+		log:          log,
+		stats:        stats,
+		mAbandoned:   stats.GetCounter("reconcile.abandoned"),
+		mRestarts:    stats.GetCounter("manager.restarts"),
+		mTimeouts:    stats.GetCounter("reconcile.timeout"),
+		mCoalesced:   stats.GetCounter("reconcile.coalesced"),
+		mWatchErrors: stats.GetCounter("watch.error"),
+		mLimited:     stats.GetCounter("rate_limit.count"),
+		mLimitFor:    stats.GetCounter("rate_limit.total_ms"),
+		mLimitErr:    stats.GetCounter("rate_limit.error"),
+		mLatency:     stats.GetTimerVec("reconcile.latency", []string{"gvk"}),
+		mRelisted:    stats.GetCounter("relist.count"),
+		mHeartbeats:  stats.GetCounter("heartbeat.count"),
+		mErrors:      kclient.NewErrorCounter(stats),
 
-		resChan:          make(chan types.Response),
+		events:           newEventTracker(time.Now()),
+		tombstones:       newTombstoneTracker(),
+		previous:         newPreviousTracker(),
+		objectLocks:      newKeyedMutex(),
+		coalescer:        newCoalescer(),
+		sync:             newSyncTracker(),
+		syncMarker:       conf.SyncMarker,
+		emitWatchErrors:  conf.WatchErrors.Emit,
 		transactionsChan: make(chan types.Transaction),
 		closeChan:        make(chan struct{}),
+		abandonChan:      make(chan struct{}),
 		closedChan:       make(chan struct{}),
 	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	// obtain rate limit resource
+	if conf.RateLimit != "" {
+		rl, err := mgr.GetRateLimit(conf.RateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain rate limit resource: %v", err)
+		}
+		c.rateLimit = rl
+	}
+
+	// bound the number of concurrent in-flight transactions
+	if conf.MaxInFlight < 0 {
+		return nil, fmt.Errorf("invalid max_in_flight: %d", conf.MaxInFlight)
+	}
+	if conf.MaxInFlight > 0 {
+		c.inFlightSlots = make(chan struct{}, conf.MaxInFlight)
+	}
+
+	// parse reconcile timeout
+	if conf.ReconcileTimeout != "" {
+		timeout, err := time.ParseDuration(conf.ReconcileTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing reconcile_timeout: %v", err)
+		}
+		c.reconcileTimeout = timeout
+	}
+
+	// parse shutdown timeout
+	if conf.ShutdownTimeout != "" {
+		timeout, err := time.ParseDuration(conf.ShutdownTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing shutdown_timeout: %v", err)
+		}
+		c.shutdownTimeout = timeout
+	}
+
+	// parse heartbeat interval
+	if conf.HeartbeatInterval != "" {
+		interval, err := time.ParseDuration(conf.HeartbeatInterval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing heartbeat_interval: %v", err)
+		}
+		if interval < 0 {
+			return nil, fmt.Errorf("invalid heartbeat_interval: %s", conf.HeartbeatInterval)
+		}
+		c.heartbeatInterval = interval
+	}
+
+	// parse restart config
+	c.restartMaxAttempts = conf.Restart.MaxAttempts
+	if conf.Restart.InitialInterval != "" {
+		interval, err := time.ParseDuration(conf.Restart.InitialInterval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing restart initial_interval: %v", err)
+		}
+		c.restartInitialInterval = interval
+	}
+	if conf.Restart.MaxInterval != "" {
+		interval, err := time.ParseDuration(conf.Restart.MaxInterval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing restart max_interval: %v", err)
+		}
+		c.restartMaxInterval = interval
+	}
 
 	// check for result requeue mapping
 	if conf.Result.Requeue != "" {
@@ -269,29 +812,287 @@ func NewKubernetes(
 		}
 		c.requeueAfter = requeueAfter
 	}
+	if conf.Result.RequeueAfterJitter < 0 || conf.Result.RequeueAfterJitter >= 100 {
+		return nil, fmt.Errorf("invalid result requeue_after_jitter: %v", conf.Result.RequeueAfterJitter)
+	}
+	c.requeueAfterJitter = conf.Result.RequeueAfterJitter
 
 	// initalize controller manager
-	cmgr, err := manager.New(config.GetConfigOrDie(), manager.Options{})
+	res, err := kclient.GetResource(mgr, conf.Client, "input", conf.Config, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := res.RestConfig()
 	if err != nil {
-		log.Errorf("error initializing controller manager: %v", err)
 		return nil, err
 	}
+	c.resource = res
+	c.restConfig = rc
+	c.watches = conf.Watches
 
-	// register watches
-	for _, w := range conf.Watches {
-		gvk := w.GVK()
-		if err := w.Register(cmgr, c.Reconciler(gvk)); err != nil {
-			log.Errorf("error registering controller: %v", err)
+	// resolve the versions of watched kinds prior to deriving names, filters,
+	// and caches from them
+	for i := range c.watches {
+		if err := c.watches[i].resolveVersions(res); err != nil {
+			return nil, err
+		}
+	}
+	if c.watches, err = expandTriggerWatches(c.watches); err != nil {
+		return nil, err
+	}
+
+	// validate watch modes, which must be consistent across all watches
+	var listWatches int
+	for i := range c.watches {
+		if c.watches[i].CoalesceWindow != "" {
+			window, err := time.ParseDuration(c.watches[i].CoalesceWindow)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing coalesce_window: %v", err)
+			}
+			c.watches[i].coalesceWindow = window
+		}
+		if c.watches[i].RelistInterval != "" {
+			interval, err := time.ParseDuration(c.watches[i].RelistInterval)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing relist_interval: %v", err)
+			}
+			c.watches[i].relistInterval = interval
+		}
+		if c.watches[i].Mapping != "" {
+			m, err := bloblang.NewMapping(c.watches[i].Mapping)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing mapping: %v", err)
+			}
+			c.watches[i].mapping = m
+		}
+		if _, err := c.watches[i].FilterPredicates(); err != nil {
+			return nil, err
+		}
+		if _, err := c.watches[i].Options(); err != nil {
+			return nil, err
+		}
+		if _, err := c.watches[i].NamespaceLabelSelector(); err != nil {
+			return nil, err
+		}
+		for _, field := range c.watches[i].IndexFields {
+			if field == "" || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") {
+				return nil, fmt.Errorf("invalid index field: %q", field)
+			}
+		}
+		if c.watches[i].IncludePrevious && !c.watches[i].ShouldFetch() {
+			return nil, errors.New("include_previous cannot be used with fetch_object disabled")
+		}
+		if c.watches[i].MetadataOnly && (c.watches[i].Typed || c.watches[i].WatchStatusOnly) {
+			return nil, errors.New("metadata_only cannot be used with typed or watch_status_only")
+		}
+		switch c.watches[i].Mode {
+		case "", WatchModeWatch:
+		case WatchModeList:
+			if c.watches[i].SkipInitialList {
+				return nil, errors.New("skip_initial_list is not supported by list mode watches")
+			}
+			if c.watches[i].MetadataOnly {
+				return nil, errors.New("metadata_only is not supported by list mode watches")
+			}
+			if c.watches[i].IncludePrevious {
+				return nil, errors.New("include_previous is not supported by list mode watches")
+			}
+			if c.watches[i].relistInterval > 0 {
+				return nil, errors.New("relist_interval is not supported by list mode watches")
+			}
+			listWatches++
+		default:
+			return nil, fmt.Errorf("invalid watch mode: %s", c.watches[i].Mode)
+		}
+	}
+	if err := assignWatchNames(c.watches); err != nil {
+		return nil, err
+	}
+	if listWatches > 0 && listWatches < len(c.watches) {
+		return nil, errors.New("list mode watches cannot be combined with watch mode watches")
+	}
+	c.listMode = listWatches > 0
+	if c.listMode && c.heartbeatInterval > 0 {
+		return nil, errors.New("heartbeat_interval is not supported by list mode watches")
+	}
+
+	// guard against watches that unintentionally span all namespaces
+	if conf.RequireNamespaces {
+		mapper, err := res.Mapper()
+		if err != nil {
+			return nil, err
+		}
+		if err := c.requireNamespaces(mapper); err != nil {
+			return nil, err
+		}
+	}
+
+	// fail fast if the input is not permitted to list and watch its kinds
+	if conf.ValidatePermissions {
+		if err := c.validatePermissions(res); err != nil {
+			return nil, err
+		}
+	}
+
+	// collect server side list filters to push down into the cache
+	c.listFilters = map[schema.GroupVersionKind]listFilter{}
+	for i := range c.watches {
+		filter, ok, err := c.watches[i].ListFilter()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		gvk := c.watches[i].GVK()
+		if _, exists := c.listFilters[gvk]; exists {
+			return nil, fmt.Errorf("multiple watches with selectors for %s", gvk.String())
+		}
+		c.listFilters[gvk] = filter
+	}
+
+	// metadata only informers cannot be shared with watches of full objects
+	for i := range c.watches {
+		gvk := c.watches[i].GVK()
+		if filter, ok := c.listFilters[gvk]; ok && filter.MetadataOnly && !c.watches[i].MetadataOnly {
+			return nil, fmt.Errorf("metadata_only watches cannot be combined with other watches for %s", gvk.String())
+		}
+	}
+
+	if !c.listMode {
+		cmgr, err := c.newManager()
+		if err != nil {
 			return nil, err
 		}
-		log.Infof("registered controller for %s", gvk.String())
+		c.mgr = cmgr
 	}
 
-	c.mgr = cmgr
 	go c.loop()
 	return c, nil
 }
 
+// assignWatchNames assigns a unique controller name to each watch, where
+// explicit names must be unique and default names derived from the GVK are
+// suffixed with an index when shared by multiple watches
+func assignWatchNames(watches []Watch) error {
+	used := map[string]bool{}
+	for i := range watches {
+		name := watches[i].Name
+		if name == "" {
+			continue
+		}
+		if used[name] {
+			return fmt.Errorf("duplicate watch name: %s", name)
+		}
+		used[name] = true
+		watches[i].name = name
+	}
+	for i := range watches {
+		if watches[i].Name != "" {
+			continue
+		}
+		base := watches[i].defaultName()
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		watches[i].name = name
+	}
+	return nil
+}
+
+// newManager initializes a new controller manager and registers all watches
+func (k *Kubernetes) newManager() (manager.Manager, error) {
+	cmgr, err := manager.New(k.restConfig, manager.Options{
+		MapperProvider: func(*rest.Config) (meta.RESTMapper, error) {
+			return k.resource.Mapper()
+		},
+		NewCache: newFilteredCacheFunc(k.listFilters),
+	})
+	if err != nil {
+		k.log.Errorf("error initializing controller manager: %v", err)
+		return nil, err
+	}
+
+	// register watches
+	for i := range k.watches {
+		w := &k.watches[i]
+		gvk := w.GVK()
+		if w.Typed && !cmgr.GetScheme().Recognizes(gvk) {
+			k.log.Warnf("%s is not registered in the client scheme, falling back to unstructured objects", gvk.String())
+		}
+		var preds []predicate.Predicate
+		if w.SkipInitialList {
+			filter, err := k.newInitialListFilter(cmgr, w)
+			if err != nil {
+				k.log.Errorf("error initializing initial list filter: %v", err)
+				return nil, err
+			}
+			preds = append(preds, filter)
+		}
+		if w.namespaces, err = k.newNamespaceMatcher(cmgr, w); err != nil {
+			k.log.Errorf("error initializing namespace selector: %v", err)
+			return nil, err
+		}
+		if w.namespaces != nil {
+			preds = append(preds, w.namespaces.Predicate())
+		}
+		if w.nsLabels, err = k.newNamespaceLabels(cmgr, w); err != nil {
+			k.log.Errorf("error initializing namespace labels: %v", err)
+			return nil, err
+		}
+		preds = append(preds, k.events.Predicate(gvk), k.tombstones.Predicate(gvk))
+		if w.IncludePrevious {
+			preds = append(preds, k.previous.Predicate(gvk))
+		}
+		if w.relistInterval > 0 {
+			w.relist = make(chan event.GenericEvent)
+			if err := cmgr.Add(k.relistRunnable(cmgr, w)); err != nil {
+				k.log.Errorf("error registering relist: %v", err)
+				return nil, err
+			}
+		}
+		if err := w.Register(cmgr, k.Reconciler(cmgr, w), preds...); err != nil {
+			k.log.Errorf("error registering controller: %v", err)
+			return nil, err
+		}
+		k.log.Infof("registered controller %s for %s", w.name, gvk.String())
+	}
+
+	// index fields prior to starting the manager, as informers cannot be
+	// indexed once started
+	if err := k.registerIndexes(cmgr); err != nil {
+		k.log.Errorf("error registering field indexes: %v", err)
+		return nil, err
+	}
+
+	// share the informer cache with other plugins using the same client once
+	// it has synced
+	if err := cmgr.Add(k.shareCacheRunnable(cmgr)); err != nil {
+		k.log.Errorf("error registering shared cache: %v", err)
+		return nil, err
+	}
+
+	// emit a marker once the initial set of objects has been dispatched
+	if k.syncMarker {
+		if err := cmgr.Add(k.syncRunnable(cmgr)); err != nil {
+			k.log.Errorf("error registering sync marker: %v", err)
+			return nil, err
+		}
+	}
+
+	// emit heartbeats while the manager is running
+	if k.heartbeatInterval > 0 {
+		if err := cmgr.Add(k.heartbeatRunnable()); err != nil {
+			k.log.Errorf("error registering heartbeat: %v", err)
+			return nil, err
+		}
+	}
+
+	return cmgr, nil
+}
+
 // Connected returns true if this input is currently connected to its target.
 func (k *Kubernetes) Connected() bool {
 	return true
@@ -307,6 +1108,7 @@ func (k *Kubernetes) TransactionChan() <-chan types.Transaction {
 func (k *Kubernetes) CloseAsync() {
 	k.closeOnce.Do(func() {
 		close(k.closeChan)
+		k.cancel()
 	})
 }
 
@@ -328,42 +1130,249 @@ func (k *Kubernetes) loop() {
 		close(k.closedChan)
 	}()
 
-	if err := k.mgr.Start(k.closeChan); err != nil {
+	if k.listMode {
+		k.list()
+		return
+	}
+
+	mgr := k.mgr
+	defer k.registerWatchErrors(mgr.GetScheme())()
+
+	interval := k.restartInitialInterval
+	for attempt := 0; ; attempt++ {
+		// recreate the manager with capped exponential backoff following a
+		// start failure
+		if attempt > 0 {
+			if attempt > k.restartMaxAttempts {
+				k.log.Errorf("manager failed to start after %d restart attempts, giving up", k.restartMaxAttempts)
+				break
+			}
+			k.log.Warnf("restarting manager in %s (attempt %d of %d)", interval, attempt, k.restartMaxAttempts)
+			select {
+			case <-time.After(interval):
+			case <-k.closeChan:
+			}
+			if interval *= 2; interval > k.restartMaxInterval {
+				interval = k.restartMaxInterval
+			}
+			if k.isClosing() {
+				break
+			}
+			k.mRestarts.Incr(1)
+
+			var err error
+			if mgr, err = k.newManager(); err != nil {
+				continue
+			}
+		}
+
+		// the manager does not wait for its runnables to return, so shared
+		// caches are also unregistered here prior to any restart
+		err := mgr.Start(k.closeChan)
+		for i := range k.watches {
+			k.resource.UnregisterCache(k.watches[i].GVK(), mgr.GetCache())
+		}
+		if err == nil || k.isClosing() {
+			break
+		}
 		k.log.Errorf("error running manager: %v", err)
 	}
+	k.drain()
+}
+
+// waitForAccess blocks until the rate limit, if any, permits a transaction,
+// returning false if the input is closed while waiting
+func (k *Kubernetes) waitForAccess() bool {
+	if k.rateLimit == nil {
+		return true
+	}
+	for {
+		period, err := k.rateLimit.Access()
+		if err != nil {
+			k.log.Errorf("rate limit error: %v", err)
+			k.mLimitErr.Incr(1)
+			period = time.Second
+		}
+		if period <= 0 {
+			return true
+		}
+		if err == nil {
+			k.mLimited.Incr(1)
+			k.mLimitFor.Incr(period.Nanoseconds() / int64(time.Millisecond))
+		}
+		select {
+		case <-time.After(period):
+		case <-k.closeChan:
+			return false
+		}
+	}
+}
+
+// acquireSlot blocks until an in-flight transaction slot is available,
+// returning false if the input is closed while waiting
+func (k *Kubernetes) acquireSlot() bool {
+	if k.inFlightSlots == nil {
+		return true
+	}
+	select {
+	case k.inFlightSlots <- struct{}{}:
+		return true
+	case <-k.closeChan:
+		return false
+	}
+}
+
+// releaseSlot releases an in-flight transaction slot
+func (k *Kubernetes) releaseSlot() {
+	if k.inFlightSlots != nil {
+		<-k.inFlightSlots
+	}
+}
+
+// isClosing returns true if the input has been instructed to close
+func (k *Kubernetes) isClosing() bool {
+	select {
+	case <-k.closeChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain stops the admission of new reconcile transactions and waits up to the
+// configured shutdown timeout for in-flight transactions to be acknowledged,
+// after which any remaining transactions are abandoned
+func (k *Kubernetes) drain() {
+	k.inFlightMut.Lock()
+	k.closing = true
+	k.inFlightMut.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		k.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return
+	case <-time.After(k.shutdownTimeout):
+	}
+
+	k.log.Warnf("shutdown timeout exceeded, abandoning in-flight reconciles")
+	close(k.abandonChan)
+	<-drained
+}
+
+// admit registers a new in-flight reconcile transaction, returning false if
+// the input is shutting down
+func (k *Kubernetes) admit() bool {
+	k.inFlightMut.Lock()
+	defer k.inFlightMut.Unlock()
+	if k.closing {
+		return false
+	}
+	k.inFlight.Add(1)
+	return true
 }
 
 //------------------------------------------------------------------------------
 
-// Reconciler returns a reconciler function scoped to the specified GVK
-func (k *Kubernetes) Reconciler(gvk schema.GroupVersionKind) reconcile.Reconciler {
+// Reconciler returns a reconciler function scoped to the specified manager and
+// watch
+func (k *Kubernetes) Reconciler(mgr manager.Manager, w *Watch) reconcile.Reconciler {
+	gvk := w.GVK()
+	mLatency := k.mLatency.With(gvk.String())
 	return reconcile.Func(func(req reconcile.Request) (reconcile.Result, error) {
 		resp := reconcile.Result{}
-		fields := map[string]string{
-			"group":     gvk.Group,
-			"kind":      gvk.Kind,
-			"namespace": req.Namespace,
-			"name":      req.Name,
-			"version":   gvk.Version,
-		}
+		fields := objectFields(gvk, req.Namespace, req.Name)
 		log := k.log.WithFields(fields)
 
-		u := unstructured.Unstructured{}
-		u.SetGroupVersionKind(gvk)
-		u.SetNamespace(req.Namespace)
-		u.SetName(req.Name)
+		obj := w.NewObject(mgr.GetScheme())
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			log.Errorf("error accessing object metadata: %v", err)
+			return resp, err
+		}
+		objMeta.SetNamespace(req.Namespace)
+		objMeta.SetName(req.Name)
 
-		if err := k.mgr.GetCache().Get(context.Background(), req.NamespacedName, &u); err != nil {
-			if err := client.IgnoreNotFound(err); err != nil {
-				log.Debugf("error fetching object: %v", err)
-				return resp, err
+		key := gvk.String() + "/" + req.NamespacedName.String()
+
+		// requests enqueued by owned objects bypass the watch predicates, and
+		// are ignored when the object is assigned to another shard
+		if !w.Sharding.Matches(req.Namespace, req.Name) {
+			return resp, nil
+		}
+
+		// debounce reconciles of the same object, such that only the latest
+		// state is emitted once the coalesce window elapses
+		if w.coalesceWindow > 0 {
+			if delay, coalesced := k.coalescer.Delay(key, w.coalesceWindow); delay > 0 {
+				if coalesced {
+					k.mCoalesced.Incr(1)
+				}
+				return reconcile.Result{RequeueAfter: delay}, nil
 			}
-			fields["deleted"] = "1"
+		}
+		eventType := eventUpdated
+		if k.events.Created(key) {
+			eventType = eventCreated
 		}
 
-		b, err := u.MarshalJSON()
-		if err != nil {
-			log.Errorf("error marshalling object: %v", err)
+		// when fetching is disabled, the message contains only the object
+		// metadata fields and an empty body, and deletions are not detected
+		var b []byte
+		if w.ShouldFetch() {
+			if err := mgr.GetCache().Get(k.ctx, req.NamespacedName, obj); err != nil {
+				if k.isClosing() {
+					k.log.Infoln("input closing...")
+					return resp, nil
+				}
+				if err := client.IgnoreNotFound(err); err != nil {
+					log.Debugf("error fetching object: %v", err)
+					k.mErrors.Incr(err)
+					return resp, err
+				}
+				fields["deleted"] = "1"
+				eventType = eventDeleted
+
+				// populate the last known metadata of the deleted object
+				if ts, ok := k.tombstones.Get(key); ok {
+					ts.apply(objMeta)
+					for name, value := range ts.fields() {
+						fields[name] = value
+					}
+				}
+			} else {
+				k.tombstones.Forget(key)
+			}
+
+			// typed objects read from the cache do not include type metadata
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+			if w.IncludePrevious {
+				b, err = k.marshalChange(w, key, obj, eventType)
+			} else {
+				b, err = w.Marshal(obj, eventVerbs[eventType])
+			}
+			if err != nil {
+				if err == errObjectDropped {
+					log.Debugf("object dropped by mapping")
+					k.sync.Dispatched(key)
+					k.events.Forget(key)
+					k.tombstones.Forget(key)
+					k.previous.Forget(key)
+					return resp, nil
+				}
+				log.Errorf("error marshalling object: %v", err)
+				return resp, err
+			}
+		}
+		fields["event_type"] = eventType
+		if err := w.nsLabels.addFields(req.Namespace, fields); err != nil {
+			log.Errorf("error propagating namespace labels: %v", err)
+			k.mErrors.Incr(err)
 			return resp, err
 		}
 
@@ -372,61 +1381,373 @@ func (k *Kubernetes) Reconciler(gvk schema.GroupVersionKind) reconcile.Reconcile
 		msg := message.New(nil)
 		msg.Append(part)
 
-		store := roundtrip.NewResultStore()
-		roundtrip.AddResultStore(msg, store)
+		// attach a result store unless results are not collected, in which
+		// case the transaction is only acknowledged
+		var store roundtrip.ResultStore
+		if w.ShouldCollectResults() {
+			store = roundtrip.NewResultStore()
+			roundtrip.AddResultStore(msg, store)
+		}
+
+		// stop accepting new reconciles once the input begins closing
+		if !k.admit() {
+			k.log.Infoln("input closing...")
+			return resp, nil
+		}
+
+		// serialize transactions for a given object so that responses for the
+		// same object are never interleaved, while allowing distinct objects
+		// to be processed in parallel
+		k.objectLocks.Lock(key)
+
+		// bound the number of concurrent in-flight transactions
+		if !k.acquireSlot() {
+			k.objectLocks.Unlock(key)
+			k.inFlight.Done()
+			k.log.Infoln("input closing...")
+			return resp, nil
+		}
+
+		// the object lock, slot, and in-flight transaction are released once
+		// the transaction completes, which may outlive a reconcile that times
+		// out
+		release := func() {
+			k.releaseSlot()
+			k.objectLocks.Unlock(key)
+			k.inFlight.Done()
+		}
+		pending := false
+		defer func() {
+			if !pending {
+				release()
+			}
+		}()
+
+		// throttle transactions using the configured rate limit
+		if !k.waitForAccess() {
+			k.log.Infoln("input closing...")
+			return resp, nil
+		}
 
-		// send batch to downstream processors
+		// send batch to downstream processors, buffering the response channel
+		// so that a response arriving after a timeout never blocks the sender
+		resChan := make(chan types.Response, 1)
+		start := time.Now()
 		select {
-		case k.transactionsChan <- types.NewTransaction(msg, k.resChan):
+		case k.transactionsChan <- types.NewTransaction(msg, resChan):
 		case <-k.closeChan:
 			k.log.Infoln("input closing...")
 			return resp, nil
 		}
+		k.sync.Dispatched(key)
+
+		var timeout <-chan time.Time
+		if k.reconcileTimeout > 0 {
+			timer := time.NewTimer(k.reconcileTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
 
-		// check transaction success
+		// check transaction success, waiting for in-flight transactions to be
+		// acknowledged during shutdown until the shutdown timeout is exceeded
 		select {
-		case result := <-k.resChan:
+		case result := <-resChan:
+			mLatency.Timing(time.Since(start).Nanoseconds())
+
 			// handle error
 			if err := result.Error(); err != nil {
 				log.Errorln(err.Error())
 				return resp, err
 			}
-		case <-k.closeChan:
+			k.events.Forget(key)
+			k.tombstones.Forget(key)
+			k.previous.Forget(key)
+		case <-timeout:
+			log.Errorf("transaction not acknowledged within reconcile_timeout of %s", k.reconcileTimeout)
+			k.mTimeouts.Incr(1)
+
+			// hold the object lock and slot until the pending transaction is
+			// acknowledged, such that the requeued reconcile is never in
+			// flight alongside it
+			pending = true
+			go func() {
+				defer release()
+				select {
+				case <-resChan:
+					log.Infoln("transaction acknowledged after reconcile_timeout")
+				case <-k.abandonChan:
+				}
+			}()
+			return resp, fmt.Errorf("reconcile timed out after %s", k.reconcileTimeout)
+		case <-k.abandonChan:
+			log.Warnln("abandoning reconcile due to shutdown")
+			k.mAbandoned.Incr(1)
+			return resp, nil
 		}
 
-		// combine result messages if more than one exist
-		result := message.New(nil)
-		for _, resMsg := range store.Get() {
-			resMsg.Iter(func(i int, part types.Part) error {
-				result.Append(part)
-				return nil
-			})
+		if store == nil {
+			return resp, nil
 		}
+		return k.result(log, store.Get()), nil
+	})
+}
 
-		if result.Len() > 0 {
-			if k.requeue != nil {
-				requeue, err := k.requeue.QueryPart(0, result)
-				if err != nil {
-					log.Errorf("failed to check result requeue mapping: %v", err)
-				} else if requeue {
-					log.Debugln("requeueing object")
-					resp.Requeue = true
-				}
+// marshalChange encodes the previous and current state of a reconciled object,
+// where the previous state of an updated object is the state prior to the
+// first update since it was last emitted, if observed, and the previous state
+// of a deleted object is its tombstone
+func (k *Kubernetes) marshalChange(w *Watch, key string, obj runtime.Object, eventType string) ([]byte, error) {
+	switch eventType {
+	case eventCreated:
+		return w.MarshalChange(nil, obj, eventVerbs[eventType])
+	case eventDeleted:
+		return w.MarshalChange(obj, nil, eventVerbs[eventType])
+	}
+	prev, ok := k.previous.Get(key)
+	if !ok {
+		return w.MarshalChange(nil, obj, eventVerbs[eventType])
+	}
+	// cached objects must not be modified, and typed objects read from the
+	// cache do not include type metadata
+	old := prev.DeepCopyObject()
+	old.GetObjectKind().SetGroupVersionKind(w.GVK())
+	return w.MarshalChange(old, obj, eventVerbs[eventType])
+}
+
+// result derives the reconcile result from the result messages returned by the
+// pipeline, if any. All parts of all result messages are considered, such that
+// the object is requeued if any part satisfies the requeue mapping, and is
+// requeued after the shortest valid requeue_after duration of any part. When
+// no result messages are returned, the object is not requeued.
+func (k *Kubernetes) result(log log.Modular, results []types.Message) reconcile.Result {
+	resp := reconcile.Result{}
+	if k.requeue == nil && k.requeueAfter == nil {
+		return resp
+	}
+
+	// combine result messages into a single batch
+	result := message.New(nil)
+	for _, resMsg := range results {
+		if resMsg == nil {
+			continue
+		}
+		resMsg.Iter(func(i int, part types.Part) error {
+			result.Append(part)
+			return nil
+		})
+	}
+
+	for i := 0; i < result.Len(); i++ {
+		if k.requeue != nil && !resp.Requeue {
+			requeue, err := k.requeue.QueryPart(i, result)
+			if err != nil {
+				log.Errorf("failed to check result requeue mapping: %v", err)
+			} else if requeue {
+				resp.Requeue = true
 			}
-			if k.requeueAfter != nil {
-				requeueAfter := k.requeueAfter.String(0, result)
-				if requeueAfter != "" {
-					requeueAfterDur, err := time.ParseDuration(requeueAfter)
-					if err != nil {
-						log.Warnf("invalid requeue_after duration: %s", requeueAfter)
-					} else {
-						log.Debugf("requeueing object after %s", requeueAfter)
-						resp.RequeueAfter = requeueAfterDur
-					}
-				}
+		}
+		if k.requeueAfter != nil {
+			requeueAfter := k.requeueAfter.String(i, result)
+			if requeueAfter == "" {
+				continue
+			}
+			requeueAfterDur, err := time.ParseDuration(requeueAfter)
+			if err != nil || requeueAfterDur <= 0 {
+				log.Warnf("invalid requeue_after duration: %s", requeueAfter)
+				continue
+			}
+			if resp.RequeueAfter == 0 || requeueAfterDur < resp.RequeueAfter {
+				resp.RequeueAfter = requeueAfterDur
 			}
 		}
+	}
 
-		return resp, nil
-	})
+	if resp.RequeueAfter > 0 {
+		resp.RequeueAfter = jitter(resp.RequeueAfter, k.requeueAfterJitter)
+		log.Debugf("requeueing object after %s", resp.RequeueAfter)
+	} else if resp.Requeue {
+		log.Debugln("requeueing object")
+	}
+	return resp
+}
+
+//------------------------------------------------------------------------------
+
+// keyedMutex provides mutual exclusion scoped to individual keys
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedMutexEntry
+}
+
+type keyedMutexEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{
+		locks: map[string]*keyedMutexEntry{},
+	}
+}
+
+// Lock acquires the lock for the given key
+func (m *keyedMutex) Lock(key string) {
+	m.mu.Lock()
+	e, ok := m.locks[key]
+	if !ok {
+		e = &keyedMutexEntry{}
+		m.locks[key] = e
+	}
+	e.refs++
+	m.mu.Unlock()
+
+	e.mu.Lock()
+}
+
+// Unlock releases the lock for the given key
+func (m *keyedMutex) Unlock(key string) {
+	m.mu.Lock()
+	e := m.locks[key]
+	e.refs--
+	if e.refs == 0 {
+		delete(m.locks, key)
+	}
+	m.mu.Unlock()
+
+	e.mu.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+// coalescer tracks per key deadlines used to debounce reconciles
+type coalescer struct {
+	mu        sync.Mutex
+	deadlines map[string]time.Time
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{
+		deadlines: map[string]time.Time{},
+	}
+}
+
+// Delay returns the amount of time to wait before the given key should be
+// emitted, starting a new window if none is pending, and whether the call was
+// coalesced into an already pending window. A zero delay indicates that the
+// window has elapsed and the key should be emitted.
+func (c *coalescer) Delay(key string, window time.Duration) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	deadline, pending := c.deadlines[key]
+	if !pending {
+		c.deadlines[key] = now.Add(window)
+		return window, false
+	}
+	if remaining := deadline.Sub(now); remaining > 0 {
+		return remaining, true
+	}
+	delete(c.deadlines, key)
+	return 0, false
+}
+
+// jitterRand is seeded per process, such that replicas (and restarts) do not
+// share the sequence of the default source, which is seeded with 1
+var (
+	jitterRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	jitterRandMu sync.Mutex
+)
+
+// jitter randomly adjusts a duration by up to the given percentage in either
+// direction, such that objects requeued after the same duration are spread
+// out over time
+func jitter(d time.Duration, percent float64) time.Duration {
+	if percent <= 0 {
+		return d
+	}
+	jitterRandMu.Lock()
+	r := jitterRand.Float64()
+	jitterRandMu.Unlock()
+	factor := 1 + (r*2-1)*percent/100
+	return time.Duration(float64(d) * factor)
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	eventCreated = "created"
+	eventDeleted = "deleted"
+	eventUpdated = "updated"
+)
+
+// objectFields returns the metadata fields identifying an object
+func objectFields(gvk schema.GroupVersionKind, namespace, name string) map[string]string {
+	apiVersion := gvk.GroupVersion().String()
+	return map[string]string{
+		"api_version": apiVersion,
+		"group":       gvk.Group,
+		"gvk":         apiVersion + "/" + gvk.Kind,
+		"kind":        gvk.Kind,
+		"namespace":   namespace,
+		"name":        name,
+		"version":     gvk.Version,
+	}
+}
+
+// eventVerbs maps event types to the values used in body_path envelopes
+var eventVerbs = map[string]string{
+	eventCreated: "create",
+	eventDeleted: "delete",
+	eventUpdated: "update",
+}
+
+// eventTracker records objects observed via create events so that the
+// reconciler can distinguish between created and updated objects, which is not
+// otherwise possible given that reconcile requests do not include the
+// triggering event. Objects with a creation timestamp that predates the input
+// start time are considered pre-existing and are reported as updated.
+type eventTracker struct {
+	mu      sync.Mutex
+	created map[string]struct{}
+	started time.Time
+}
+
+func newEventTracker(started time.Time) *eventTracker {
+	return &eventTracker{
+		created: map[string]struct{}{},
+		started: started.Truncate(time.Second),
+	}
+}
+
+// Predicate returns a predicate that records create events for the given GVK
+// without filtering any events
+func (t *eventTracker) Predicate(gvk schema.GroupVersionKind) predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			if !e.Meta.GetCreationTimestamp().Time.Before(t.started) {
+				key := gvk.String() + "/" + e.Meta.GetNamespace() + "/" + e.Meta.GetName()
+				t.mu.Lock()
+				t.created[key] = struct{}{}
+				t.mu.Unlock()
+			}
+			return true
+		},
+	}
+}
+
+// Created returns true if a create event has been observed for the given key
+// that has not yet been successfully reconciled
+func (t *eventTracker) Created(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.created[key]
+	return ok
+}
+
+// Forget clears any recorded create event for the given key
+func (t *eventTracker) Forget(key string) {
+	t.mu.Lock()
+	delete(t.created, key)
+	t.mu.Unlock()
 }
@@ -0,0 +1,216 @@
+package input
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	tests := []struct {
+		name      string
+		held      string
+		acquire   string
+		exclusive bool
+	}{
+		{name: "same key", held: "v1/Pod/default/a", acquire: "v1/Pod/default/a", exclusive: true},
+		{name: "different name", held: "v1/Pod/default/a", acquire: "v1/Pod/default/b", exclusive: false},
+		{name: "different namespace", held: "v1/Pod/default/a", acquire: "v1/Pod/other/a", exclusive: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newKeyedMutex()
+			m.Lock(test.held)
+
+			acquired := make(chan struct{})
+			go func() {
+				m.Lock(test.acquire)
+				close(acquired)
+			}()
+
+			select {
+			case <-acquired:
+				if test.exclusive {
+					t.Fatalf("lock for %s acquired while %s was held", test.acquire, test.held)
+				}
+			case <-time.After(50 * time.Millisecond):
+				if !test.exclusive {
+					t.Fatalf("lock for %s blocked by %s", test.acquire, test.held)
+				}
+			}
+
+			m.Unlock(test.held)
+			select {
+			case <-acquired:
+			case <-time.After(time.Second):
+				t.Fatalf("lock for %s not acquired after %s was released", test.acquire, test.held)
+			}
+			m.Unlock(test.acquire)
+
+			if n := len(m.locks); n != 0 {
+				t.Errorf("expected all lock entries to be released, got %d", n)
+			}
+		})
+	}
+}
+
+func TestKeyedMutexRelease(t *testing.T) {
+	m := newKeyedMutex()
+	const key = "v1/Pod/default/a"
+
+	// waiters share an entry, which is only released by the last holder
+	m.Lock(key)
+	done := make(chan struct{})
+	go func() {
+		m.Lock(key)
+		m.Unlock(key)
+		close(done)
+	}()
+	for {
+		m.mu.Lock()
+		refs := m.locks[key].refs
+		m.mu.Unlock()
+		if refs == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	m.Unlock(key)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not released")
+	}
+	if _, ok := m.locks[key]; ok {
+		t.Error("expected lock entry to be released")
+	}
+
+	// released keys can be reacquired
+	m.Lock(key)
+	m.Unlock(key)
+	if n := len(m.locks); n != 0 {
+		t.Errorf("expected all lock entries to be released, got %d", n)
+	}
+}
+
+func TestCoalescerDelay(t *testing.T) {
+	const window = 100 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		sleep     time.Duration
+		coalesced bool
+		maxDelay  time.Duration
+		minDelay  time.Duration
+	}{
+		{name: "within window", sleep: 10 * time.Millisecond, coalesced: true, minDelay: time.Nanosecond, maxDelay: window - 10*time.Millisecond},
+		{name: "window elapsed", sleep: window + 10*time.Millisecond, coalesced: false, minDelay: 0, maxDelay: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCoalescer()
+			const key = "v1/Pod/default/a"
+
+			delay, coalesced := c.Delay(key, window)
+			if delay != window || coalesced {
+				t.Fatalf("expected new window of %s, got %s (coalesced: %v)", window, delay, coalesced)
+			}
+
+			time.Sleep(test.sleep)
+			delay, coalesced = c.Delay(key, window)
+			if coalesced != test.coalesced {
+				t.Errorf("expected coalesced %v, got %v", test.coalesced, coalesced)
+			}
+			if delay < test.minDelay || delay > test.maxDelay {
+				t.Errorf("expected delay between %s and %s, got %s", test.minDelay, test.maxDelay, delay)
+			}
+		})
+	}
+}
+
+func TestCoalescerDelayCapped(t *testing.T) {
+	const window = 100 * time.Millisecond
+	c := newCoalescer()
+	const key = "v1/Pod/default/a"
+
+	// repeated events never extend the window beyond the deadline set by the
+	// first event, such that a steady stream of events is still emitted
+	start := time.Now()
+	c.Delay(key, window)
+	for i := 0; i < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		delay, coalesced := c.Delay(key, window)
+		if !coalesced {
+			t.Fatalf("expected event %d to be coalesced", i)
+		}
+		// allow for the time elapsed between starting the clock and the
+		// first event
+		if deadline := time.Since(start) + delay; deadline > window+5*time.Millisecond {
+			t.Fatalf("expected deadline within %s of the first event, got %s", window, deadline)
+		}
+	}
+
+	// other keys have independent windows
+	if delay, coalesced := c.Delay("v1/Pod/default/b", window); delay != window || coalesced {
+		t.Errorf("expected new window of %s for other key, got %s (coalesced: %v)", window, delay, coalesced)
+	}
+
+	// the window is cleared once elapsed, such that the next event starts a
+	// new window
+	time.Sleep(window)
+	if delay, coalesced := c.Delay(key, window); delay != 0 || coalesced {
+		t.Fatalf("expected elapsed window, got %s (coalesced: %v)", delay, coalesced)
+	}
+	if delay, coalesced := c.Delay(key, window); delay != window || coalesced {
+		t.Errorf("expected new window of %s, got %s (coalesced: %v)", window, delay, coalesced)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	const d = 10 * time.Second
+
+	tests := []struct {
+		name    string
+		percent float64
+		min     time.Duration
+		max     time.Duration
+	}{
+		{name: "disabled", percent: 0, min: d, max: d},
+		{name: "negative", percent: -10, min: d, max: d},
+		{name: "ten percent", percent: 10, min: 9 * time.Second, max: 11 * time.Second},
+		{name: "fifty percent", percent: 50, min: 5 * time.Second, max: 15 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seen := map[time.Duration]bool{}
+			for i := 0; i < 100; i++ {
+				got := jitter(d, test.percent)
+				if got < test.min || got > test.max {
+					t.Fatalf("expected duration between %s and %s, got %s", test.min, test.max, got)
+				}
+				seen[got] = true
+			}
+			if test.percent > 0 && len(seen) < 2 {
+				t.Errorf("expected jittered durations to vary, got %v", seen)
+			}
+		})
+	}
+}
+
+func TestJitterSeeded(t *testing.T) {
+	// the default source is seeded with 1 in every process, such that a
+	// sequence matching it would be shared by every replica
+	defaultRand := rand.New(rand.NewSource(1))
+	const percent = 50
+	for i := 0; i < 10; i++ {
+		expected := time.Duration(float64(time.Second) * (1 + (defaultRand.Float64()*2-1)*percent/100))
+		if jitter(time.Second, percent) != expected {
+			return
+		}
+	}
+	t.Error("expected jitter to differ from the sequence of the default source")
+}
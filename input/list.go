@@ -0,0 +1,227 @@
+package input
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	bmeta "github.com/Jeffail/benthos/v3/lib/message/metadata"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// Supported watch modes
+const (
+	WatchModeList  = "list"
+	WatchModeWatch = "watch"
+)
+
+// listPageSize is the maximum number of objects requested per list call
+const listPageSize = 500
+
+// Backoff between attempts to send a message that was not acknowledged, which
+// doubles after each attempt
+const (
+	sendRetryInitialInterval = time.Second
+	sendRetryMaxInterval     = 30 * time.Second
+)
+
+//------------------------------------------------------------------------------
+
+// list performs a single list of each watch, emitting every object as an
+// individual transaction and waiting for it to be acknowledged before
+// returning
+func (k *Kubernetes) list() {
+	c, err := k.resource.Client()
+	if err != nil {
+		k.log.Errorf("error initializing client: %v", err)
+		return
+	}
+
+	for i := range k.watches {
+		w := &k.watches[i]
+		if err := k.listWatch(c, w); err != nil {
+			if err != types.ErrTypeClosed && !k.isClosing() {
+				k.log.Errorf("error listing %s: %v", w.GVK().String(), err)
+			}
+			return
+		}
+	}
+	if k.syncMarker {
+		if err := k.emitSyncComplete(); err != nil {
+			if err != types.ErrTypeClosed && !k.isClosing() {
+				k.log.Errorf("error emitting sync complete marker: %v", err)
+			}
+			return
+		}
+	}
+	k.log.Infoln("all listed objects acknowledged, closing input")
+}
+
+// listWatch lists and emits all objects matching a single watch
+func (k *Kubernetes) listWatch(c client.Client, w *Watch) error {
+	gvk := w.GVK()
+
+	var opts []client.ListOption
+	selector, err := w.LabelSelector()
+	if err != nil {
+		return err
+	}
+	if selector != nil {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+	if w.FieldSelector != "" {
+		fieldSelector, err := fields.ParseSelector(w.FieldSelector)
+		if err != nil {
+			return fmt.Errorf("error parsing field_selector: %v", err)
+		}
+		opts = append(opts, client.MatchingFieldsSelector{Selector: fieldSelector})
+	}
+
+	matchesOwner := func([]metav1.OwnerReference) bool { return true }
+	if w.OwnedBy != "" {
+		if matchesOwner, err = newOwnedByMatcher(w.OwnedBy, false); err != nil {
+			return err
+		}
+	}
+
+	matchesPredicate := func(*unstructured.Unstructured) bool { return true }
+	if w.Predicate != "" {
+		p, err := newMappingPredicate(w.Predicate)
+		if err != nil {
+			return err
+		}
+		matchesPredicate = func(u *unstructured.Unstructured) bool {
+			return p.Generic(event.GenericEvent{Meta: u, Object: u})
+		}
+	}
+
+	w.nsLabels = k.newListNamespaceLabels(c, w)
+
+	namespaces := w.Namespaces
+	nsSelector, err := w.NamespaceLabelSelector()
+	if err != nil {
+		return err
+	}
+	if nsSelector != nil {
+		if namespaces, err = k.selectedNamespaces(c, w, nsSelector); err != nil {
+			return fmt.Errorf("error listing namespaces: %v", err)
+		}
+	} else if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	for _, ns := range namespaces {
+		var cont string
+		for {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			pageOpts := append([]client.ListOption{
+				client.InNamespace(ns),
+				client.Limit(listPageSize),
+				client.Continue(cont),
+			}, opts...)
+			if err := c.List(k.ctx, list, pageOpts...); err != nil {
+				k.mErrors.Incr(err)
+				return err
+			}
+
+			for j := range list.Items {
+				if !w.Sharding.Matches(list.Items[j].GetNamespace(), list.Items[j].GetName()) {
+					continue
+				}
+				if !matchesOwner(list.Items[j].GetOwnerReferences()) || !matchesPredicate(&list.Items[j]) {
+					continue
+				}
+				if err := k.emit(w, &list.Items[j]); err != nil {
+					return err
+				}
+			}
+
+			if cont = list.GetContinue(); cont == "" {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// emit sends a listed object downstream, retrying until it is acknowledged or
+// the input is closed
+func (k *Kubernetes) emit(w *Watch, u *unstructured.Unstructured) error {
+	fields := objectFields(w.GVK(), u.GetNamespace(), u.GetName())
+	fields["event_type"] = eventUpdated
+	log := k.log.WithFields(fields)
+
+	b, err := w.Marshal(u, eventVerbs[eventUpdated])
+	if err == errObjectDropped {
+		log.Debugf("object dropped by mapping")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error marshalling object: %v", err)
+	}
+	if err := w.nsLabels.addFields(u.GetNamespace(), fields); err != nil {
+		return fmt.Errorf("error propagating namespace labels: %v", err)
+	}
+
+	return k.sendAcked(func() types.Message {
+		part := message.NewPart(b)
+		part.SetMetadata(bmeta.New(fields))
+		msg := message.New(nil)
+		msg.Append(part)
+		return msg
+	}, log)
+}
+
+// sendAcked sends a message downstream, retrying with capped exponential
+// backoff until it is acknowledged or the input is closed. The message is
+// tracked as an in-flight transaction, such that it is never sent once the
+// input begins closing, while a message already sent is waited on until the
+// shutdown timeout is exceeded, as with reconcile transactions.
+func (k *Kubernetes) sendAcked(newMsg func() types.Message, log log.Modular) error {
+	if !k.admit() {
+		return types.ErrTypeClosed
+	}
+	defer k.inFlight.Done()
+
+	interval := sendRetryInitialInterval
+	for {
+		if !k.waitForAccess() {
+			return types.ErrTypeClosed
+		}
+
+		resChan := make(chan types.Response, 1)
+		select {
+		case k.transactionsChan <- types.NewTransaction(newMsg(), resChan):
+		case <-k.closeChan:
+			return types.ErrTypeClosed
+		case <-k.abandonChan:
+			return types.ErrTypeClosed
+		}
+
+		select {
+		case res := <-resChan:
+			if res.Error() == nil {
+				return nil
+			}
+			log.Errorln(res.Error().Error())
+		case <-k.abandonChan:
+			return types.ErrTypeClosed
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-k.closeChan:
+			return types.ErrTypeClosed
+		}
+		if interval *= 2; interval > sendRetryMaxInterval {
+			interval = sendRetryMaxInterval
+		}
+	}
+}
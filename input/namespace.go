@@ -0,0 +1,269 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+//------------------------------------------------------------------------------
+
+// namespaceMatcher matches the namespaces of objects against the namespace
+// selector of a watch. Namespaces are read from the manager cache, such that
+// changes to namespace labels are observed at runtime.
+type namespaceMatcher struct {
+	ctx      context.Context
+	informer cache.Informer
+	reader   client.Reader
+	selector labels.Selector
+	list     func(opts ...client.ListOption) ([]runtime.Object, error)
+}
+
+// newNamespaceMatcher returns a matcher for the namespace selector of a watch,
+// or nil if not specified, which must be called prior to starting the manager
+func (k *Kubernetes) newNamespaceMatcher(mgr manager.Manager, w *Watch) (*namespaceMatcher, error) {
+	selector, err := w.NamespaceLabelSelector()
+	if err != nil || selector == nil {
+		return nil, err
+	}
+	informer, err := mgr.GetCache().GetInformer(k.ctx, &corev1.Namespace{})
+	if err != nil {
+		return nil, err
+	}
+	return &namespaceMatcher{
+		ctx:      k.ctx,
+		informer: informer,
+		reader:   mgr.GetCache(),
+		selector: selector,
+		list: func(opts ...client.ListOption) ([]runtime.Object, error) {
+			return k.cachedObjects(mgr, w, opts...)
+		},
+	}, nil
+}
+
+// Matches returns true if the given namespace exists and matches the selector,
+// waiting for the namespace informer to sync such that events delivered at
+// startup are not dropped
+func (m *namespaceMatcher) Matches(namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+	if !m.informer.HasSynced() && !toolscache.WaitForCacheSync(m.ctx.Done(), m.informer.HasSynced) {
+		return false
+	}
+	var ns corev1.Namespace
+	if err := m.reader.Get(m.ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			predicateLog.Error(err, "error getting namespace", "namespace", namespace)
+		}
+		return false
+	}
+	return m.selector.Matches(labels.Set(ns.GetLabels()))
+}
+
+// Predicate returns a predicate that admits events for objects in matching
+// namespaces
+func (m *namespaceMatcher) Predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return m.Matches(e.Meta.GetNamespace())
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return m.Matches(e.Meta.GetNamespace())
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return m.Matches(e.Meta.GetNamespace())
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return m.Matches(e.MetaNew.GetNamespace())
+		},
+	}
+}
+
+// transitionPredicate returns a predicate that admits namespace updates in
+// which the namespace starts matching the selector
+func (m *namespaceMatcher) transitionPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !m.selector.Matches(labels.Set(e.MetaOld.GetLabels())) &&
+				m.selector.Matches(labels.Set(e.MetaNew.GetLabels()))
+		},
+	}
+}
+
+// handler returns an event handler that enqueues every cached object of the
+// watch in the namespace of a handled namespace event
+func (m *namespaceMatcher) handler() handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+			items, err := m.list(client.InNamespace(o.Meta.GetName()))
+			if err != nil {
+				predicateLog.Error(err, "error listing objects", "namespace", o.Meta.GetName())
+				return nil
+			}
+			reqs := make([]reconcile.Request, 0, len(items))
+			for _, item := range items {
+				obj, err := meta.Accessor(item)
+				if err != nil {
+					continue
+				}
+				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKey{
+					Namespace: obj.GetNamespace(),
+					Name:      obj.GetName(),
+				}})
+			}
+			return reqs
+		}),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// selectedNamespaces returns the namespaces matching the namespace selector of
+// a watch, restricted to the namespaces of the watch if specified
+func (k *Kubernetes) selectedNamespaces(c client.Client, w *Watch, selector labels.Selector) ([]string, error) {
+	var list corev1.NamespaceList
+	if err := c.List(k.ctx, &list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		k.mErrors.Incr(err)
+		return nil, err
+	}
+
+	allowed := make(map[string]struct{}, len(w.Namespaces))
+	for _, ns := range w.Namespaces {
+		allowed[ns] = struct{}{}
+	}
+	namespaces := []string{}
+	for _, ns := range list.Items {
+		if _, ok := allowed[ns.Name]; ok || len(allowed) == 0 {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return namespaces, nil
+}
+
+//------------------------------------------------------------------------------
+
+// namespaceLabelPrefix prefixes the metadata fields containing propagated
+// namespace labels
+const namespaceLabelPrefix = "ns_label_"
+
+// namespaceLabels copies the configured labels of the namespace of an object
+// into message metadata
+type namespaceLabels struct {
+	ctx    context.Context
+	reader client.Reader
+	keys   []string
+
+	// memo caches namespace labels when reading directly from the api server
+	memo map[string]map[string]string
+	mut  sync.Mutex
+}
+
+// newNamespaceLabels returns namespace labels for a watch that read namespaces
+// from the informer cache of the manager, or nil if not specified, which must
+// be called prior to starting the manager
+func (k *Kubernetes) newNamespaceLabels(mgr manager.Manager, w *Watch) (*namespaceLabels, error) {
+	if len(w.NamespaceLabels) == 0 {
+		return nil, nil
+	}
+	if _, err := mgr.GetCache().GetInformer(k.ctx, &corev1.Namespace{}); err != nil {
+		return nil, err
+	}
+	return &namespaceLabels{
+		ctx:    k.ctx,
+		reader: mgr.GetCache(),
+		keys:   w.NamespaceLabels,
+	}, nil
+}
+
+// newListNamespaceLabels returns namespace labels for a list mode watch that
+// read each namespace from the api server at most once, or nil if not
+// specified
+func (k *Kubernetes) newListNamespaceLabels(c client.Reader, w *Watch) *namespaceLabels {
+	if len(w.NamespaceLabels) == 0 {
+		return nil
+	}
+	return &namespaceLabels{
+		ctx:    k.ctx,
+		reader: c,
+		keys:   w.NamespaceLabels,
+		memo:   map[string]map[string]string{},
+	}
+}
+
+// addFields adds the configured labels of the given namespace to fields,
+// ignoring missing labels and namespaces
+func (n *namespaceLabels) addFields(namespace string, fields map[string]string) error {
+	if n == nil || namespace == "" {
+		return nil
+	}
+	nsLabels, err := n.get(namespace)
+	if err != nil {
+		return err
+	}
+	for _, key := range n.keys {
+		if value, ok := nsLabels[key]; ok {
+			fields[namespaceLabelPrefix+key] = value
+		}
+	}
+	return nil
+}
+
+// get returns the labels of the given namespace
+func (n *namespaceLabels) get(namespace string) (map[string]string, error) {
+	if n.memo != nil {
+		n.mut.Lock()
+		defer n.mut.Unlock()
+		if nsLabels, ok := n.memo[namespace]; ok {
+			return nsLabels, nil
+		}
+	}
+	var ns corev1.Namespace
+	if err := n.reader.Get(n.ctx, client.ObjectKey{Name: namespace}, &ns); client.IgnoreNotFound(err) != nil {
+		return nil, fmt.Errorf("error getting namespace %s: %v", namespace, err)
+	}
+	if n.memo != nil {
+		n.memo[namespace] = ns.GetLabels()
+	}
+	return ns.GetLabels(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// requireNamespaces verifies that every watch of a namespaced kind is scoped
+// to namespaces, via either namespaces or a namespace_selector, guarding
+// against watches that unintentionally reconcile objects in all namespaces
+func (k *Kubernetes) requireNamespaces(mapper meta.RESTMapper) error {
+	for i := range k.watches {
+		w := &k.watches[i]
+		if len(w.Namespaces) > 0 || w.NamespaceSelector != nil {
+			continue
+		}
+		gvk := w.GVK()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("error mapping %s: %v", gvk.String(), err)
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			return fmt.Errorf("watch %s of namespaced kind %s requires namespaces or namespace_selector", w.name, gvk.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+var predicateLog = logf.Log.WithName("predicate")
+
+// Supported change predicates
+const (
+	PredicateAnnotation      = "annotation"
+	PredicateGeneration      = "generation"
+	PredicateLabel           = "label"
+	PredicateResourceVersion = "resource_version"
+	PredicateStatus          = "status"
+)
+
+// newChangePredicate returns a predicate that admits update events matching
+// any of the named change predicates or changing any of the given dot
+// separated field paths, and all other events
+func newChangePredicate(names []string, fields []string) (predicate.Predicate, error) {
+	var preds []predicate.Predicate
+	for _, name := range names {
+		switch name {
+		case PredicateAnnotation:
+			preds = append(preds, predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					if e.MetaOld == nil || e.MetaNew == nil {
+						return false
+					}
+					return !reflect.DeepEqual(e.MetaOld.GetAnnotations(), e.MetaNew.GetAnnotations())
+				},
+			})
+		case PredicateGeneration:
+			preds = append(preds, predicate.GenerationChangedPredicate{})
+		case PredicateLabel:
+			preds = append(preds, predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					if e.MetaOld == nil || e.MetaNew == nil {
+						return false
+					}
+					return !reflect.DeepEqual(e.MetaOld.GetLabels(), e.MetaNew.GetLabels())
+				},
+			})
+		case PredicateResourceVersion:
+			preds = append(preds, predicate.ResourceVersionChangedPredicate{})
+		case PredicateStatus:
+			preds = append(preds, predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					if e.ObjectOld == nil || e.ObjectNew == nil {
+						return false
+					}
+					return !reflect.DeepEqual(objectStatus(e.ObjectOld), objectStatus(e.ObjectNew))
+				},
+			})
+		default:
+			return nil, fmt.Errorf("invalid predicate: %s", name)
+		}
+	}
+	if len(fields) > 0 {
+		p, err := newFieldChangePredicate(fields)
+		if err != nil {
+			return nil, err
+		}
+		preds = append(preds, p)
+	}
+
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			for _, p := range preds {
+				if p.Update(e) {
+					return true
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+// newFieldChangePredicate returns a predicate that admits update events in
+// which the value at any of the given dot separated field paths differs
+// between the old and new object, where a field that is added or removed is
+// considered changed
+func newFieldChangePredicate(fields []string) (predicate.Predicate, error) {
+	paths := make([][]string, len(fields))
+	for i, field := range fields {
+		if field == "" || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+			return nil, fmt.Errorf("invalid changed field: %q", field)
+		}
+		paths[i] = strings.Split(field, ".")
+	}
+
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			oldContent, newContent := objectContent(e.ObjectOld), objectContent(e.ObjectNew)
+			for _, path := range paths {
+				oldValue, oldFound, _ := unstructured.NestedFieldNoCopy(oldContent, path...)
+				newValue, newFound, _ := unstructured.NestedFieldNoCopy(newContent, path...)
+				if oldFound != newFound || !reflect.DeepEqual(oldValue, newValue) {
+					return true
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+// objectContent returns the unstructured content of an object, or nil if the
+// object cannot be converted
+func objectContent(obj runtime.Object) map[string]interface{} {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		predicateLog.Error(err, "error converting object")
+		return nil
+	}
+	return content
+}
+
+// objectStatus returns the status subtree of an object, or nil if the object
+// has no status or cannot be converted
+func objectStatus(obj runtime.Object) interface{} {
+	return objectContent(obj)["status"]
+}
+
+// eventCountFields lists the paths of the fields of core/v1 and events.k8s.io
+// Events that are updated when a repeated event is recorded
+var eventCountFields = [][]string{
+	{"count"},
+	{"deprecatedCount"},
+	{"deprecatedLastTimestamp"},
+	{"lastTimestamp"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"series"},
+}
+
+// newEventCountPredicate returns a predicate that ignores updates to Events
+// that only record repeated occurrences (i.e. count and series changes), such
+// that a single message is emitted per logical event
+func newEventCountPredicate() predicate.Predicate {
+	strip := func(obj runtime.Object) map[string]interface{} {
+		content := runtime.DeepCopyJSON(objectContent(obj))
+		for _, path := range eventCountFields {
+			unstructured.RemoveNestedField(content, path...)
+		}
+		return content
+	}
+
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+			return !reflect.DeepEqual(strip(e.ObjectOld), strip(e.ObjectNew))
+		},
+	}
+}
+
+// Special owned_by values
+const (
+	OwnedByAny  = "any"
+	OwnedByNone = "none"
+)
+
+// newOwnedByMatcher returns a function that matches owner references, where
+// ownedBy is one of none, any, or an owner <apiVersion>/<kind> (e.g.
+// apps/v1/ReplicaSet). When controller is set, only the controller reference
+// of an object is matched against an owner <apiVersion>/<kind>.
+func newOwnedByMatcher(ownedBy string, controller bool) (func(refs []metav1.OwnerReference) bool, error) {
+	switch ownedBy {
+	case OwnedByAny:
+		return func(refs []metav1.OwnerReference) bool {
+			return len(refs) > 0
+		}, nil
+	case OwnedByNone:
+		return func(refs []metav1.OwnerReference) bool {
+			return len(refs) == 0
+		}, nil
+	}
+
+	i := strings.LastIndex(ownedBy, "/")
+	if i <= 0 || i == len(ownedBy)-1 {
+		return nil, fmt.Errorf("invalid owned_by: %s, must be one of none, any, or <apiVersion>/<kind>", ownedBy)
+	}
+	apiVersion, kind := ownedBy[:i], ownedBy[i+1:]
+	return func(refs []metav1.OwnerReference) bool {
+		for _, ref := range refs {
+			if controller && (ref.Controller == nil || !*ref.Controller) {
+				continue
+			}
+			if ref.APIVersion == apiVersion && ref.Kind == kind {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// newOwnedByPredicate returns a predicate that admits objects based on their
+// owner references
+func newOwnedByPredicate(ownedBy string, controller bool) (predicate.Predicate, error) {
+	matches, err := newOwnedByMatcher(ownedBy, controller)
+	if err != nil {
+		return nil, err
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return matches(e.Meta.GetOwnerReferences())
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return matches(e.Meta.GetOwnerReferences())
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return matches(e.Meta.GetOwnerReferences())
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return matches(e.MetaNew.GetOwnerReferences())
+		},
+	}, nil
+}
+
+// newMappingPredicate returns a predicate that admits events for which the
+// given Bloblang query returns true, where the query is evaluated against a
+// document containing the event type (create, update, delete, or generic) and
+// the old and new object states, such that update events include both
+func newMappingPredicate(query string) (predicate.Predicate, error) {
+	m, err := bloblang.NewMapping(query)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing predicate: %v", err)
+	}
+
+	matches := func(eventType string, oldObj, newObj runtime.Object) bool {
+		b, err := json.Marshal(map[string]interface{}{
+			"event": eventType,
+			"old":   oldObj,
+			"new":   newObj,
+		})
+		if err != nil {
+			predicateLog.Error(err, "error marshalling predicate document")
+			return false
+		}
+		msg := message.New(nil)
+		msg.Append(message.NewPart(b))
+		ok, err := m.QueryPart(0, msg)
+		if err != nil {
+			predicateLog.Error(err, "error evaluating predicate")
+			return false
+		}
+		return ok
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return matches("create", nil, e.Object)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return matches("delete", e.Object, nil)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return matches("generic", nil, e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return matches("update", e.ObjectOld, e.ObjectNew)
+		},
+	}, nil
+}
@@ -0,0 +1,217 @@
+package input
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// newTestObject returns a ConfigMap with the given generation, labels, and
+// status
+func newTestObject(generation int64, labels map[string]string, status map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"namespace": "default",
+			"name":      "a",
+		},
+	}}
+	u.SetGeneration(generation)
+	u.SetLabels(labels)
+	if status != nil {
+		u.Object["status"] = status
+	}
+	return u
+}
+
+// newUpdateEvent returns an update event between the given objects
+func newUpdateEvent(oldObj, newObj *unstructured.Unstructured) event.UpdateEvent {
+	return event.UpdateEvent{
+		MetaOld:   oldObj,
+		ObjectOld: oldObj,
+		MetaNew:   newObj,
+		ObjectNew: newObj,
+	}
+}
+
+func TestChangePredicate(t *testing.T) {
+	base := newTestObject(1, map[string]string{"app": "a"}, map[string]interface{}{"phase": "Pending"})
+
+	tests := []struct {
+		name  string
+		names []string
+		new   *unstructured.Unstructured
+		admit bool
+	}{
+		{name: "generation changed", names: []string{PredicateGeneration}, new: newTestObject(2, map[string]string{"app": "a"}, map[string]interface{}{"phase": "Pending"}), admit: true},
+		{name: "generation unchanged", names: []string{PredicateGeneration}, new: newTestObject(1, map[string]string{"app": "b"}, map[string]interface{}{"phase": "Pending"}), admit: false},
+		{name: "label changed", names: []string{PredicateLabel}, new: newTestObject(1, map[string]string{"app": "b"}, map[string]interface{}{"phase": "Pending"}), admit: true},
+		{name: "label added", names: []string{PredicateLabel}, new: newTestObject(1, map[string]string{"app": "a", "tier": "web"}, map[string]interface{}{"phase": "Pending"}), admit: true},
+		{name: "label unchanged", names: []string{PredicateLabel}, new: newTestObject(2, map[string]string{"app": "a"}, map[string]interface{}{"phase": "Pending"}), admit: false},
+		{name: "status changed", names: []string{PredicateStatus}, new: newTestObject(1, map[string]string{"app": "a"}, map[string]interface{}{"phase": "Running"}), admit: true},
+		{name: "status unchanged", names: []string{PredicateStatus}, new: newTestObject(2, map[string]string{"app": "b"}, map[string]interface{}{"phase": "Pending"}), admit: false},
+		{name: "any of generation or label", names: []string{PredicateGeneration, PredicateLabel}, new: newTestObject(1, map[string]string{"app": "b"}, map[string]interface{}{"phase": "Pending"}), admit: true},
+		{name: "none of generation or label", names: []string{PredicateGeneration, PredicateLabel}, new: newTestObject(1, map[string]string{"app": "a"}, map[string]interface{}{"phase": "Running"}), admit: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := newChangePredicate(test.names, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if admit := p.Update(newUpdateEvent(base, test.new)); admit != test.admit {
+				t.Errorf("expected update admitted %v, got %v", test.admit, admit)
+			}
+		})
+	}
+}
+
+func TestChangePredicateOtherEvents(t *testing.T) {
+	p, err := newChangePredicate([]string{PredicateGeneration}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := newTestObject(1, nil, nil)
+	if !p.Create(event.CreateEvent{Meta: obj, Object: obj}) {
+		t.Error("expected create to be admitted")
+	}
+	if !p.Delete(event.DeleteEvent{Meta: obj, Object: obj}) {
+		t.Error("expected delete to be admitted")
+	}
+	if !p.Generic(event.GenericEvent{Meta: obj, Object: obj}) {
+		t.Error("expected generic to be admitted")
+	}
+}
+
+func TestChangePredicateInvalid(t *testing.T) {
+	if _, err := newChangePredicate([]string{"unknown"}, nil); err == nil {
+		t.Error("expected error for unknown predicate")
+	}
+}
+
+func TestFieldChangePredicate(t *testing.T) {
+	withSpec := func(spec map[string]interface{}) *unstructured.Unstructured {
+		u := newTestObject(1, nil, nil)
+		if spec != nil {
+			u.Object["spec"] = spec
+		}
+		return u
+	}
+	base := withSpec(map[string]interface{}{
+		"replicas": int64(1),
+		"template": map[string]interface{}{"image": "nginx:1"},
+	})
+
+	tests := []struct {
+		name   string
+		fields []string
+		new    *unstructured.Unstructured
+		admit  bool
+	}{
+		{
+			name:   "scalar changed",
+			fields: []string{"spec.replicas"},
+			new:    withSpec(map[string]interface{}{"replicas": int64(2), "template": map[string]interface{}{"image": "nginx:1"}}),
+			admit:  true,
+		},
+		{
+			name:   "other field changed",
+			fields: []string{"spec.replicas"},
+			new:    withSpec(map[string]interface{}{"replicas": int64(1), "template": map[string]interface{}{"image": "nginx:2"}}),
+			admit:  false,
+		},
+		{
+			name:   "nested field changed",
+			fields: []string{"spec.template.image"},
+			new:    withSpec(map[string]interface{}{"replicas": int64(1), "template": map[string]interface{}{"image": "nginx:2"}}),
+			admit:  true,
+		},
+		{
+			name:   "parent of changed field",
+			fields: []string{"spec.template"},
+			new:    withSpec(map[string]interface{}{"replicas": int64(1), "template": map[string]interface{}{"image": "nginx:2"}}),
+			admit:  true,
+		},
+		{
+			name:   "field removed",
+			fields: []string{"spec.replicas"},
+			new:    withSpec(map[string]interface{}{"template": map[string]interface{}{"image": "nginx:1"}}),
+			admit:  true,
+		},
+		{
+			name:   "field added",
+			fields: []string{"spec.paused"},
+			new:    withSpec(map[string]interface{}{"replicas": int64(1), "paused": true, "template": map[string]interface{}{"image": "nginx:1"}}),
+			admit:  true,
+		},
+		{
+			name:   "missing in both",
+			fields: []string{"spec.paused"},
+			new:    withSpec(map[string]interface{}{"replicas": int64(2), "template": map[string]interface{}{"image": "nginx:1"}}),
+			admit:  false,
+		},
+		{
+			name:   "any of fields",
+			fields: []string{"spec.paused", "spec.replicas"},
+			new:    withSpec(map[string]interface{}{"replicas": int64(2), "template": map[string]interface{}{"image": "nginx:1"}}),
+			admit:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := newFieldChangePredicate(test.fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if admit := p.Update(newUpdateEvent(base, test.new)); admit != test.admit {
+				t.Errorf("expected update admitted %v, got %v", test.admit, admit)
+			}
+		})
+	}
+}
+
+func TestFieldChangePredicateInvalid(t *testing.T) {
+	for _, field := range []string{"", ".spec", "spec.", "spec..replicas"} {
+		if _, err := newFieldChangePredicate([]string{field}); err == nil {
+			t.Errorf("expected error for field %q", field)
+		}
+	}
+}
+
+func TestChangePredicateFields(t *testing.T) {
+	// changed fields are combined with the named change predicates, such that
+	// updates matching either are admitted
+	p, err := newChangePredicate([]string{PredicateGeneration}, []string{"spec.replicas"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldObj := newTestObject(1, nil, nil)
+	oldObj.Object["spec"] = map[string]interface{}{"replicas": int64(1)}
+
+	newObj := oldObj.DeepCopy()
+	newObj.Object["spec"] = map[string]interface{}{"replicas": int64(2)}
+	if !p.Update(newUpdateEvent(oldObj, newObj)) {
+		t.Error("expected changed field to be admitted")
+	}
+
+	newObj = oldObj.DeepCopy()
+	newObj.SetGeneration(2)
+	if !p.Update(newUpdateEvent(oldObj, newObj)) {
+		t.Error("expected changed generation to be admitted")
+	}
+
+	newObj = oldObj.DeepCopy()
+	newObj.SetLabels(map[string]string{"app": "a"})
+	if p.Update(newUpdateEvent(oldObj, newObj)) {
+		t.Error("expected unrelated change to be filtered")
+	}
+
+	if _, err := newChangePredicate(nil, []string{"spec..replicas"}); err == nil {
+		t.Error("expected error for invalid changed field")
+	}
+}
@@ -0,0 +1,64 @@
+package input
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+//------------------------------------------------------------------------------
+
+// previousTracker records the previous state of objects observed via update
+// events, given that reconcile requests only identify the object and collapse
+// multiple updates. The state prior to the first update since the object was
+// last emitted is retained until the reconcile is acknowledged.
+type previousTracker struct {
+	mu       sync.Mutex
+	previous map[string]runtime.Object
+}
+
+func newPreviousTracker() *previousTracker {
+	return &previousTracker{
+		previous: map[string]runtime.Object{},
+	}
+}
+
+// Predicate returns a predicate that records update events for the given GVK
+// without filtering any events, which must follow any filtering predicates
+// such that only updates that trigger a reconcile are recorded
+func (t *previousTracker) Predicate(gvk schema.GroupVersionKind) predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.MetaOld == nil || e.ObjectOld == nil {
+				return true
+			}
+			key := gvk.String() + "/" + e.MetaOld.GetNamespace() + "/" + e.MetaOld.GetName()
+			t.mu.Lock()
+			if _, ok := t.previous[key]; !ok {
+				// cached objects are never mutated, so the old object can be
+				// retained without copying
+				t.previous[key] = e.ObjectOld
+			}
+			t.mu.Unlock()
+			return true
+		},
+	}
+}
+
+// Get returns the previous state recorded for the given key, if any
+func (t *previousTracker) Get(key string) (runtime.Object, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	obj, ok := t.previous[key]
+	return obj, ok
+}
+
+// Forget clears any previous state recorded for the given key
+func (t *previousTracker) Forget(key string) {
+	t.mu.Lock()
+	delete(t.previous, key)
+	t.mu.Unlock()
+}
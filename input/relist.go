@@ -0,0 +1,54 @@
+package input
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+//------------------------------------------------------------------------------
+
+// relistRunnable returns a runnable that periodically enqueues every cached
+// object of a watch via the relist channel of the watch, such that the current
+// state of all objects is re-emitted regardless of the change predicates of
+// the watch
+func (k *Kubernetes) relistRunnable(mgr manager.Manager, w *Watch) manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		ticker := time.NewTicker(w.relistInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return nil
+			}
+			if err := k.relist(mgr, w, stop); err != nil {
+				k.log.Errorf("error relisting %s: %v", w.GVK().String(), err)
+			}
+		}
+	})
+}
+
+// relist sends a generic event for every cached object of a watch
+func (k *Kubernetes) relist(mgr manager.Manager, w *Watch, stop <-chan struct{}) error {
+	items, err := k.cachedObjects(mgr, w)
+	if err != nil {
+		return err
+	}
+	k.log.Debugf("relisting %d %s objects for watch %s", len(items), w.GVK().String(), w.name)
+	for _, item := range items {
+		objMeta, err := meta.Accessor(item)
+		if err != nil {
+			continue
+		}
+		select {
+		case w.relist <- event.GenericEvent{Meta: objMeta, Object: item}:
+			k.mRelisted.Incr(1)
+		case <-stop:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package input
+
+import (
+	"errors"
+	"hash/fnv"
+
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+//------------------------------------------------------------------------------
+
+// sharding partitions the objects of a watch across replicas by a consistent
+// hash of their namespace and name, which is used rather than the uid as
+// reconcile requests (e.g. those triggered by owned objects) carry only the
+// namespace and name of an object
+type sharding struct {
+	ShardCount int `json:"shard_count" yaml:"shard_count"`
+	ShardIndex int `json:"shard_index" yaml:"shard_index"`
+}
+
+// validate returns an error if the shard index is not within the shard count
+func (s *sharding) validate() error {
+	if s.ShardCount < 1 {
+		return errors.New("sharding shard_count must be at least 1")
+	}
+	if s.ShardIndex < 0 || s.ShardIndex >= s.ShardCount {
+		return errors.New("sharding shard_index must be at least 0 and less than shard_count")
+	}
+	return nil
+}
+
+// Matches returns true if the object with the given namespace and name is
+// assigned to this shard, which is always the case if sharding is disabled
+func (s *sharding) Matches(namespace, name string) bool {
+	if s == nil || s.ShardCount <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(namespace + "/" + name))
+	return int(h.Sum32()%uint32(s.ShardCount)) == s.ShardIndex
+}
+
+// Predicate returns a predicate that admits events for objects assigned to
+// this shard
+func (s *sharding) Predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return s.Matches(e.Meta.GetNamespace(), e.Meta.GetName())
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return s.Matches(e.Meta.GetNamespace(), e.Meta.GetName())
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return s.Matches(e.Meta.GetNamespace(), e.Meta.GetName())
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return s.Matches(e.MetaNew.GetNamespace(), e.MetaNew.GetName())
+		},
+	}
+}
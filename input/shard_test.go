@@ -0,0 +1,133 @@
+package input
+
+import (
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestShardingValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		shard sharding
+		err   bool
+	}{
+		{name: "single shard", shard: sharding{ShardCount: 1, ShardIndex: 0}},
+		{name: "last index", shard: sharding{ShardCount: 3, ShardIndex: 2}},
+		{name: "zero count", shard: sharding{ShardCount: 0, ShardIndex: 0}, err: true},
+		{name: "negative index", shard: sharding{ShardCount: 3, ShardIndex: -1}, err: true},
+		{name: "index out of range", shard: sharding{ShardCount: 3, ShardIndex: 3}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.shard.validate(); (err != nil) != test.err {
+				t.Errorf("expected error %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestShardingMatchesDisabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		shard *sharding
+	}{
+		{name: "nil", shard: nil},
+		{name: "single shard", shard: &sharding{ShardCount: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if !test.shard.Matches("default", fmt.Sprintf("obj-%d", i)) {
+					t.Fatalf("expected obj-%d to match", i)
+				}
+			}
+		})
+	}
+}
+
+func TestShardingMatchesStable(t *testing.T) {
+	// assignments must be stable across releases, as replicas running
+	// different versions would otherwise disagree on ownership
+	tests := []struct {
+		namespace string
+		name      string
+		count     int
+		index     int
+	}{
+		{namespace: "default", name: "a", count: 2, index: 0},
+		{namespace: "default", name: "b", count: 2, index: 1},
+		{namespace: "kube-system", name: "coredns", count: 3, index: 1},
+		{namespace: "", name: "node-1", count: 4, index: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.namespace+"/"+test.name, func(t *testing.T) {
+			for index := 0; index < test.count; index++ {
+				s := &sharding{ShardCount: test.count, ShardIndex: index}
+				if got, want := s.Matches(test.namespace, test.name), index == test.index; got != want {
+					t.Errorf("shard %d of %d: expected match %v, got %v", index, test.count, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestShardingCoverage(t *testing.T) {
+	const objects = 1000
+
+	for _, count := range []int{2, 3, 5, 8} {
+		t.Run(fmt.Sprintf("%d shards", count), func(t *testing.T) {
+			assigned := make([]int, count)
+			for i := 0; i < objects; i++ {
+				namespace, name := fmt.Sprintf("ns-%d", i%7), fmt.Sprintf("obj-%d", i)
+
+				// every object is assigned to exactly one shard
+				var matches int
+				for index := 0; index < count; index++ {
+					s := &sharding{ShardCount: count, ShardIndex: index}
+					if s.Matches(namespace, name) {
+						matches++
+						assigned[index]++
+					}
+				}
+				if matches != 1 {
+					t.Fatalf("expected %s/%s to match exactly one shard, matched %d", namespace, name, matches)
+				}
+			}
+
+			// objects are spread roughly evenly across shards
+			for index, n := range assigned {
+				if expected := objects / count; n < expected/2 || n > expected*3/2 {
+					t.Errorf("expected roughly %d objects in shard %d, got %d", expected, index, n)
+				}
+			}
+		})
+	}
+}
+
+func TestShardingPredicate(t *testing.T) {
+	s := &sharding{ShardCount: 2, ShardIndex: 0}
+	p := s.Predicate()
+
+	for i := 0; i < 20; i++ {
+		obj := &metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("obj-%d", i)}
+		want := s.Matches(obj.Namespace, obj.Name)
+		if got := p.Create(event.CreateEvent{Meta: obj}); got != want {
+			t.Errorf("create %s: expected %v, got %v", obj.Name, want, got)
+		}
+		if got := p.Update(event.UpdateEvent{MetaOld: obj, MetaNew: obj}); got != want {
+			t.Errorf("update %s: expected %v, got %v", obj.Name, want, got)
+		}
+		if got := p.Delete(event.DeleteEvent{Meta: obj}); got != want {
+			t.Errorf("delete %s: expected %v, got %v", obj.Name, want, got)
+		}
+		if got := p.Generic(event.GenericEvent{Meta: obj}); got != want {
+			t.Errorf("generic %s: expected %v, got %v", obj.Name, want, got)
+		}
+	}
+}
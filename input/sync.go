@@ -0,0 +1,200 @@
+package input
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	bmeta "github.com/Jeffail/benthos/v3/lib/message/metadata"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Metadata key and value identifying the sync complete marker message
+const (
+	SyncMetadataKey      = "benthos_kubernetes_sync"
+	SyncMetadataComplete = "complete"
+)
+
+//------------------------------------------------------------------------------
+
+// syncTracker tracks the dispatch of the objects present in the informer
+// caches once they have synced, such that a marker can be emitted after the
+// initial set of objects has been sent downstream
+type syncTracker struct {
+	mu         sync.Mutex
+	expected   bool
+	pending    map[string]struct{}
+	dispatched map[string]struct{}
+	done       chan struct{}
+	emitted    bool
+}
+
+func newSyncTracker() *syncTracker {
+	return &syncTracker{
+		pending:    map[string]struct{}{},
+		dispatched: map[string]struct{}{},
+		done:       make(chan struct{}),
+	}
+}
+
+// Dispatched records that a transaction for the given key has been sent
+// downstream
+func (t *syncTracker) Dispatched(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.expected {
+		t.dispatched[key] = struct{}{}
+		return
+	}
+	if _, ok := t.pending[key]; !ok {
+		return
+	}
+	delete(t.pending, key)
+	if len(t.pending) == 0 {
+		close(t.done)
+	}
+}
+
+// Expect sets the keys of the initial set of objects, excluding any that have
+// already been dispatched
+func (t *syncTracker) Expect(keys []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.expected {
+		return
+	}
+	t.expected = true
+	for _, key := range keys {
+		if _, ok := t.dispatched[key]; !ok {
+			t.pending[key] = struct{}{}
+		}
+	}
+	t.dispatched = nil
+	if len(t.pending) == 0 {
+		close(t.done)
+	}
+}
+
+// Done returns a channel that is closed once all expected keys have been
+// dispatched
+func (t *syncTracker) Done() <-chan struct{} {
+	return t.done
+}
+
+// Emitted returns true if the marker has already been emitted
+func (t *syncTracker) Emitted() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.emitted
+}
+
+// markEmitted returns true if the marker has not yet been emitted, and records
+// it as emitted, such that manager restarts do not emit duplicate markers
+func (t *syncTracker) markEmitted() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.emitted {
+		return false
+	}
+	t.emitted = true
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// syncRunnable returns a manager runnable that waits for the informer caches
+// of all watches to sync and the objects they contain to be dispatched, and
+// then emits the sync complete marker
+func (k *Kubernetes) syncRunnable(mgr manager.Manager) manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		if k.sync.Emitted() {
+			return nil
+		}
+
+		var keys []string
+		for i := range k.watches {
+			watchKeys, err := k.initialKeys(mgr, &k.watches[i])
+			if err != nil {
+				if k.isClosing() {
+					return nil
+				}
+				return fmt.Errorf("error listing initial objects: %v", err)
+			}
+			keys = append(keys, watchKeys...)
+		}
+		k.sync.Expect(keys)
+		k.log.Debugf("caches synced, waiting for %d initial objects to be dispatched", len(keys))
+
+		select {
+		case <-k.sync.Done():
+		case <-stop:
+			return nil
+		}
+		if !k.sync.markEmitted() {
+			return nil
+		}
+		if err := k.emitSyncComplete(); err != nil && err != types.ErrTypeClosed {
+			k.log.Errorf("error emitting sync complete marker: %v", err)
+		}
+		return nil
+	})
+}
+
+// initialKeys returns the keys of the objects in the synced cache of a watch
+// that are admitted by its filters
+func (k *Kubernetes) initialKeys(mgr manager.Manager, w *Watch) ([]string, error) {
+	// the initial list of the watch is never dispatched when suppressed
+	if w.SkipInitialList {
+		return nil, nil
+	}
+
+	gvk := w.GVK()
+	items, err := k.cachedObjects(mgr, w)
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := w.FilterPredicates()
+	if err != nil {
+		return nil, err
+	}
+	if w.namespaces != nil {
+		filters = append(filters, w.namespaces.Predicate())
+	}
+	var keys []string
+	for _, item := range items {
+		m, err := meta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		admitted := true
+		for _, p := range filters {
+			if !p.Create(event.CreateEvent{Meta: m, Object: item}) {
+				admitted = false
+				break
+			}
+		}
+		if admitted {
+			keys = append(keys, gvk.String()+"/"+m.GetNamespace()+"/"+m.GetName())
+		}
+	}
+	return keys, nil
+}
+
+// emitSyncComplete sends the sync complete marker downstream, retrying until
+// it is acknowledged or the input is closed
+func (k *Kubernetes) emitSyncComplete() error {
+	k.log.Infoln("initial sync complete")
+	return k.sendAcked(func() types.Message {
+		part := message.NewPart(nil)
+		part.SetMetadata(bmeta.New(map[string]string{
+			SyncMetadataKey: SyncMetadataComplete,
+		}))
+		msg := message.New(nil)
+		msg.Append(part)
+		return msg
+	}, k.log)
+}
@@ -0,0 +1,95 @@
+package input
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+//------------------------------------------------------------------------------
+
+// tombstone contains the last known metadata of a deleted object
+type tombstone struct {
+	annotations       map[string]string
+	deletionTimestamp *metav1.Time
+	labels            map[string]string
+	resourceVersion   string
+	uid               types.UID
+}
+
+// apply sets the last known metadata on the given object metadata
+func (t tombstone) apply(m metav1.Object) {
+	m.SetAnnotations(t.annotations)
+	m.SetDeletionTimestamp(t.deletionTimestamp)
+	m.SetLabels(t.labels)
+	m.SetResourceVersion(t.resourceVersion)
+	m.SetUID(t.uid)
+}
+
+// fields returns the metadata fields describing the tombstone
+func (t tombstone) fields() map[string]string {
+	fields := map[string]string{
+		"resource_version": t.resourceVersion,
+	}
+	if t.deletionTimestamp != nil {
+		fields["deletion_timestamp"] = t.deletionTimestamp.UTC().Format(time.RFC3339)
+	}
+	return fields
+}
+
+// tombstoneTracker records the last known metadata of objects observed via
+// delete events, given that deleted objects are no longer present in the cache
+// by the time they are reconciled
+type tombstoneTracker struct {
+	mu      sync.Mutex
+	deleted map[string]tombstone
+}
+
+func newTombstoneTracker() *tombstoneTracker {
+	return &tombstoneTracker{
+		deleted: map[string]tombstone{},
+	}
+}
+
+// Predicate returns a predicate that records delete events for the given GVK
+// without filtering any events
+func (t *tombstoneTracker) Predicate(gvk schema.GroupVersionKind) predicate.Predicate {
+	return predicate.Funcs{
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			if e.Meta == nil {
+				return true
+			}
+			key := gvk.String() + "/" + e.Meta.GetNamespace() + "/" + e.Meta.GetName()
+			t.mu.Lock()
+			t.deleted[key] = tombstone{
+				annotations:       e.Meta.GetAnnotations(),
+				deletionTimestamp: e.Meta.GetDeletionTimestamp(),
+				labels:            e.Meta.GetLabels(),
+				resourceVersion:   e.Meta.GetResourceVersion(),
+				uid:               e.Meta.GetUID(),
+			}
+			t.mu.Unlock()
+			return true
+		},
+	}
+}
+
+// Get returns the tombstone recorded for the given key, if any
+func (t *tombstoneTracker) Get(key string) (tombstone, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ts, ok := t.deleted[key]
+	return ts, ok
+}
+
+// Forget clears any tombstone recorded for the given key
+func (t *tombstoneTracker) Forget(key string) {
+	t.mu.Lock()
+	delete(t.deleted, key)
+	t.mu.Unlock()
+}
@@ -0,0 +1,155 @@
+package input
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	bmeta "github.com/Jeffail/benthos/v3/lib/message/metadata"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// WatchErrorMetadataKey identifies watch error messages, and contains the
+// error reported by the watch
+const WatchErrorMetadataKey = "benthos_kubernetes_watch_error"
+
+// KubernetesWatchErrorsConfig provides config fields for handling errors
+// encountered by the informers of watches
+type KubernetesWatchErrorsConfig struct {
+	Emit bool `json:"emit" yaml:"emit"`
+}
+
+// NewKubernetesWatchErrorsConfig returns a KubernetesWatchErrorsConfig with
+// default values
+func NewKubernetesWatchErrorsConfig() KubernetesWatchErrorsConfig {
+	return KubernetesWatchErrorsConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+// watchErrorHandlers contains the handlers of all running inputs. Informers do
+// not return list and watch errors in client-go v0.18, which instead reports
+// them via the global runtime error handlers.
+var watchErrorHandlers = struct {
+	sync.RWMutex
+	handlers map[*Kubernetes]func(error)
+}{handlers: map[*Kubernetes]func(error){}}
+
+func init() {
+	utilruntime.ErrorHandlers = append(utilruntime.ErrorHandlers, func(err error) {
+		watchErrorHandlers.RLock()
+		defer watchErrorHandlers.RUnlock()
+		for _, handle := range watchErrorHandlers.handlers {
+			handle(err)
+		}
+	})
+}
+
+// watchErrorTarget attributes informer errors to a single watch
+type watchErrorTarget struct {
+	watch *Watch
+
+	// typeName is the expected type name used by informer errors, which is
+	// the GVK of unstructured objects, or the go type of typed objects
+	typeName string
+
+	// pending is set while a watch error message is in flight, such that
+	// repeated errors do not queue up behind an unacknowledged message
+	pending bool
+	mut     sync.Mutex
+}
+
+// registerWatchErrors attributes informer list and watch errors to watches
+// until the returned function is called
+func (k *Kubernetes) registerWatchErrors(scheme *runtime.Scheme) func() {
+	targets := make([]*watchErrorTarget, len(k.watches))
+	for i := range k.watches {
+		w := &k.watches[i]
+		typeName := w.GVK().String()
+		if _, ok := w.NewObject(scheme).(*unstructured.Unstructured); !ok {
+			typeName = reflect.TypeOf(w.NewObject(scheme)).String()
+		}
+		targets[i] = &watchErrorTarget{watch: w, typeName: typeName}
+	}
+
+	watchErrorHandlers.Lock()
+	watchErrorHandlers.handlers[k] = func(err error) {
+		msg := err.Error()
+		for _, t := range targets {
+			if strings.Contains(msg, "Failed to list "+t.typeName+": ") || strings.Contains(msg, "Failed to watch "+t.typeName+": ") {
+				k.handleWatchError(t, msg)
+			}
+		}
+	}
+	watchErrorHandlers.Unlock()
+
+	return func() {
+		watchErrorHandlers.Lock()
+		delete(watchErrorHandlers.handlers, k)
+		watchErrorHandlers.Unlock()
+	}
+}
+
+// handleWatchError records an error reported by the informer of a watch, and
+// emits a watch error message downstream if configured
+func (k *Kubernetes) handleWatchError(t *watchErrorTarget, reason string) {
+	gvk := t.watch.GVK()
+	k.mWatchErrors.Incr(1)
+	k.log.Warnf("watch %s of %s is unhealthy: %s", t.watch.name, gvk.String(), reason)
+	if !k.emitWatchErrors {
+		return
+	}
+
+	t.mut.Lock()
+	if t.pending {
+		t.mut.Unlock()
+		return
+	}
+	t.pending = true
+	t.mut.Unlock()
+
+	// the message is tracked as an in-flight transaction, and dropped if the
+	// input is closing, such that it is never sent once the transaction
+	// channel is closed
+	if !k.admit() {
+		t.mut.Lock()
+		t.pending = false
+		t.mut.Unlock()
+		return
+	}
+
+	fields := objectFields(gvk, "", "")
+	fields["watch"] = t.watch.name
+	fields[WatchErrorMetadataKey] = reason
+
+	// informers report errors synchronously, so the message is sent without
+	// blocking the informer
+	go func() {
+		defer k.inFlight.Done()
+		defer func() {
+			t.mut.Lock()
+			t.pending = false
+			t.mut.Unlock()
+		}()
+		err := k.sendAcked(func() types.Message {
+			b, _ := json.Marshal(map[string]string{
+				"error": reason,
+				"gvk":   fields["gvk"],
+				"watch": t.watch.name,
+			})
+			part := message.NewPart(b)
+			part.SetMetadata(bmeta.New(fields))
+			msg := message.New(nil)
+			msg.Append(part)
+			return msg
+		}, k.log)
+		if err != nil && err != types.ErrTypeClosed {
+			k.log.Errorf("error emitting watch error: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// applyMetadata server-side applies only the labels and annotations of an
+// object, such that the field manager owns just those fields and the rest of
+// the object is left untouched. The apply is conditioned on the resource
+// version of the live object, which prevents the apply from creating objects
+// that do not exist, and is retried following a resource version conflict.
+func (k *Kubernetes) applyMetadata(ctx context.Context, u *unstructured.Unstructured, opts ...client.PatchOption) error {
+	for attempt := 0; ; attempt++ {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(u.GroupVersionKind())
+		key := client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}
+		if err := k.client.Get(ctx, key, current); err != nil {
+			return err
+		}
+
+		partial := partialObjectMetadata(u)
+		partial.SetResourceVersion(current.GetResourceVersion())
+		err := k.client.Patch(ctx, partial, client.Apply, opts...)
+		if err == nil {
+			u.Object = partial.Object
+			return nil
+		}
+		// field manager conflicts are not retried
+		if !apierrors.IsConflict(err) || len(applyConflicts(err)) > 0 || attempt >= clientApplyMaxRetries {
+			return err
+		}
+		k.log.Debugf("conflict applying metadata of %s, retrying", objectID(u))
+	}
+}
+
+// partialObjectMetadata returns an apply configuration containing only the
+// type, identity, labels, and annotations of an object
+func partialObjectMetadata(u *unstructured.Unstructured) *unstructured.Unstructured {
+	partial := &unstructured.Unstructured{}
+	partial.SetGroupVersionKind(u.GroupVersionKind())
+	partial.SetNamespace(u.GetNamespace())
+	partial.SetName(u.GetName())
+	if labels := u.GetLabels(); len(labels) > 0 {
+		partial.SetLabels(labels)
+	}
+	if annotations := u.GetAnnotations(); len(annotations) > 0 {
+		partial.SetAnnotations(annotations)
+	}
+	return partial
+}
@@ -0,0 +1,117 @@
+package output
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/mergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// clientApplyMaxRetries is the number of times a client-side apply patch is
+// retried following a conflict, matching kubectl
+const clientApplyMaxRetries = 5
+
+//------------------------------------------------------------------------------
+
+// clientApply replicates the client-side apply behavior of kubectl, creating
+// the object if it does not exist, and otherwise patching it with a three-way
+// merge between the last applied configuration, the desired object, and the
+// live object
+func (k *Kubernetes) clientApply(ctx context.Context, u *unstructured.Unstructured) error {
+	modified, err := lastAppliedConfiguration(u)
+	if err != nil {
+		return fmt.Errorf("error encoding last applied configuration: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(u.GroupVersionKind())
+		key := client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}
+		if err := k.client.Get(ctx, key, current); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error getting object: %v", err)
+			}
+			return k.client.Create(ctx, u)
+		}
+
+		patchType, patch, err := k.threeWayPatch(u, modified, current)
+		if err != nil {
+			return fmt.Errorf("error computing patch: %v", err)
+		}
+		if string(patch) == "{}" {
+			u.Object = current.Object
+			return nil
+		}
+
+		target := u.DeepCopy()
+		err = k.client.Patch(ctx, target, client.RawPatch(patchType, patch))
+		if err == nil {
+			u.Object = target.Object
+			return nil
+		}
+		if !apierrors.IsConflict(err) || attempt >= clientApplyMaxRetries {
+			return err
+		}
+		k.log.Debugf("conflict applying %s, retrying", objectID(u))
+	}
+}
+
+// lastAppliedConfiguration sets the last-applied-configuration annotation of
+// the given object to its own encoding (excluding the annotation), returning
+// the encoding of the annotated object
+func lastAppliedConfiguration(u *unstructured.Unstructured) ([]byte, error) {
+	annotations := u.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	delete(annotations, corev1.LastAppliedConfigAnnotation)
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(u.Object, "metadata", "annotations")
+	} else {
+		u.SetAnnotations(annotations)
+	}
+
+	original, err := u.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	annotations[corev1.LastAppliedConfigAnnotation] = string(original)
+	u.SetAnnotations(annotations)
+	return u.MarshalJSON()
+}
+
+// threeWayPatch computes a three-way merge patch, using a strategic merge
+// patch for built-in types and a JSON merge patch otherwise, as kubectl does
+func (k *Kubernetes) threeWayPatch(u *unstructured.Unstructured, modified []byte, current *unstructured.Unstructured) (types.PatchType, []byte, error) {
+	original := []byte(current.GetAnnotations()[corev1.LastAppliedConfigAnnotation])
+	currentB, err := current.MarshalJSON()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if obj, err := k.scheme.New(u.GroupVersionKind()); err == nil {
+		if _, ok := obj.(*unstructured.Unstructured); !ok {
+			meta, err := strategicpatch.NewPatchMetaFromStruct(obj)
+			if err != nil {
+				return "", nil, err
+			}
+			patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, currentB, meta, true)
+			return types.StrategicMergePatchType, patch, err
+		}
+	}
+
+	preconditions := []mergepatch.PreconditionFunc{
+		mergepatch.RequireKeyUnchanged("apiVersion"),
+		mergepatch.RequireKeyUnchanged("kind"),
+		mergepatch.RequireMetadataKeyUnchanged("name"),
+	}
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, currentB, preconditions...)
+	return types.MergePatchType, patch, err
+}
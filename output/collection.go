@@ -0,0 +1,69 @@
+package output
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// deleteCollection deletes all objects of the kind and namespace of the given
+// object that match the label selector, returning the number of objects
+// deleted. Matching objects are listed prior to deletion in order to count
+// them, and are deleted individually if the kind does not support collection
+// deletion.
+func (k *Kubernetes) deleteCollection(ctx context.Context, u *unstructured.Unstructured, selector labels.Selector, policy metav1.DeletionPropagation) (int, error) {
+	gvk := u.GroupVersionKind()
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+	var listOpts []client.ListOption
+	if ns := u.GetNamespace(); ns != "" {
+		listOpts = append(listOpts, client.InNamespace(ns))
+	}
+	listOpts = append(listOpts, client.MatchingLabelsSelector{Selector: selector})
+	if err := k.client.List(ctx, list, listOpts...); err != nil {
+		return 0, fmt.Errorf("error listing objects: %v", err)
+	}
+	if len(list.Items) == 0 {
+		return 0, nil
+	}
+
+	opts := []client.DeleteAllOfOption{
+		client.MatchingLabelsSelector{Selector: selector},
+		client.PropagationPolicy(policy),
+	}
+	if ns := u.GetNamespace(); ns != "" {
+		opts = append(opts, client.InNamespace(ns))
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	err := k.client.DeleteAllOf(ctx, obj, opts...)
+	if err == nil {
+		return len(list.Items), nil
+	}
+	if !apierrors.IsMethodNotSupported(err) {
+		return 0, err
+	}
+
+	// fall back to deleting matching objects individually
+	k.log.Debugf("collection deletion not supported by %s, deleting %d objects individually", gvk.String(), len(list.Items))
+	deleted := 0
+	for i := range list.Items {
+		item := &list.Items[i]
+		if err := k.client.Delete(ctx, item, client.PropagationPolicy(policy)); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return deleted, fmt.Errorf("error deleting %s: %v", objectID(item), err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// WaitForDeletionConfig defines runtime configuration for waiting for deleted
+// objects to be removed
+type WaitForDeletionConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// NewWaitForDeletionConfig returns a WaitForDeletionConfig with default values
+func NewWaitForDeletionConfig() WaitForDeletionConfig {
+	return WaitForDeletionConfig{
+		Timeout: "5m",
+	}
+}
+
+// deletionPollInterval is the interval at which a deleted object is polled
+// while waiting for its removal
+const deletionPollInterval = time.Second
+
+//------------------------------------------------------------------------------
+
+// waitForDeletion polls a deleted object until it no longer exists or the
+// timeout elapses, where a timeout of zero waits indefinitely. When a uid is
+// specified, an object with the same name but a different uid is considered a
+// replacement, such that the deleted object no longer exists.
+func (k *Kubernetes) waitForDeletion(ctx context.Context, u *unstructured.Unstructured, uid ktypes.UID, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(u.GroupVersionKind())
+	key := client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}
+	for {
+		err := k.client.Get(ctx, key, existing)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out waiting for deletion: %v", ctx.Err())
+			}
+			return fmt.Errorf("error getting object: %v", err)
+		}
+		if uid != "" && existing.GetUID() != uid {
+			return nil
+		}
+		select {
+		case <-time.After(deletionPollInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for deletion: %v", ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package output
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// finalize adds and/or removes a finalizer of the live object identified by
+// the given object using a read-modify-write that is retried on conflict, such
+// that concurrent changes by other controllers are never overwritten
+func (k *Kubernetes) finalize(ctx context.Context, u *unstructured.Unstructured, add, remove string) error {
+	if add == "" && remove == "" {
+		return errors.New("add_finalizer or remove_finalizer is required")
+	}
+
+	key := client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(u.GroupVersionKind())
+		if err := k.client.Get(ctx, key, live); err != nil {
+			// an object that no longer exists has no finalizers to remove
+			if apierrors.IsNotFound(err) && add == "" {
+				return nil
+			}
+			return err
+		}
+
+		finalizers := live.GetFinalizers()
+		if add != "" {
+			finalizers = addFinalizer(finalizers, add)
+		}
+		if remove != "" {
+			finalizers = removeFinalizer(finalizers, remove)
+		}
+		if equalFinalizers(finalizers, live.GetFinalizers()) {
+			u.Object = live.Object
+			return nil
+		}
+
+		live.SetFinalizers(finalizers)
+		if err := k.client.Update(ctx, live); err != nil {
+			return err
+		}
+		u.Object = live.Object
+		return nil
+	})
+}
+
+// addFinalizer returns the finalizers with the given finalizer appended, if
+// not already present
+func addFinalizer(finalizers []string, finalizer string) []string {
+	for _, f := range finalizers {
+		if f == finalizer {
+			return finalizers
+		}
+	}
+	return append(append([]string{}, finalizers...), finalizer)
+}
+
+// removeFinalizer returns the finalizers without the given finalizer
+func removeFinalizer(finalizers []string, finalizer string) []string {
+	result := make([]string, 0, len(finalizers))
+	for _, f := range finalizers {
+		if f != finalizer {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// equalFinalizers returns true if both lists contain the same finalizers in
+// the same order
+func equalFinalizers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Supported immutable field error handling
+const (
+	OnImmutableError    = "error"
+	OnImmutableRecreate = "recreate"
+	OnImmutableSkip     = "skip"
+)
+
+//------------------------------------------------------------------------------
+
+// immutableFields returns the fields reported by an invalid error as being
+// immutable, returning nil if the error was not caused by an immutable field
+func immutableFields(err error) []string {
+	if !apierrors.IsInvalid(err) {
+		return nil
+	}
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return nil
+	}
+
+	var fields []string
+	for _, cause := range status.Status().Details.Causes {
+		if strings.Contains(cause.Message, "field is immutable") {
+			fields = append(fields, cause.Field)
+		}
+	}
+	return fields
+}
+
+// handleImmutable handles a write error caused by a change to immutable fields
+// according to on_immutable, returning nil if the object was skipped or
+// recreated, and the original error otherwise
+func (k *Kubernetes) handleImmutable(ctx context.Context, p types.Part, u *unstructured.Unstructured, err error, create func() error) error {
+	if k.onImmutable == OnImmutableError {
+		return err
+	}
+	fields := immutableFields(err)
+	if len(fields) == 0 {
+		return err
+	}
+	if b, jerr := json.Marshal(fields); jerr == nil {
+		p.Metadata().Set("immutable_fields", string(b))
+	}
+
+	if k.onImmutable == OnImmutableSkip {
+		k.log.Warnf("skipping %s due to changes to immutable fields: %s", objectID(u), strings.Join(fields, ", "))
+		p.Metadata().Set("operation", "skipped")
+		return nil
+	}
+
+	k.log.Warnf("recreating %s due to changes to immutable fields: %s", objectID(u), strings.Join(fields, ", "))
+	if err := k.recreate(ctx, p, u, create); err != nil {
+		return fmt.Errorf("error recreating object: %v", err)
+	}
+	p.Metadata().Set("operation", "recreated")
+	return nil
+}
+
+// recreate deletes an object, waits for the deletion to complete, and then
+// creates the object again
+func (k *Kubernetes) recreate(ctx context.Context, p types.Part, u *unstructured.Unstructured, create func() error) error {
+	policy, err := k.propagationPolicy(p)
+	if err != nil {
+		return err
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(u.GroupVersionKind())
+	existing.SetNamespace(u.GetNamespace())
+	existing.SetName(u.GetName())
+	if err := k.client.Delete(ctx, existing, &client.DeleteOptions{PropagationPolicy: &policy}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("error deleting object: %v", err)
+	}
+
+	// the object may linger while finalizers run or its dependents are
+	// deleted in the foreground
+	if err := k.waitForDeletion(ctx, u, "", k.recreateTimeout); err != nil {
+		return err
+	}
+
+	u.SetResourceVersion("")
+	u.SetUID("")
+	u.SetCreationTimestamp(metav1.Time{})
+	u.SetDeletionTimestamp(nil)
+	u.SetManagedFields(nil)
+	return create()
+}
@@ -0,0 +1,74 @@
+package output
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestImmutableFields(t *testing.T) {
+	// the api server reports immutable fields as invalid causes with the
+	// message produced by apimachinery's ValidateImmutableField
+	kind := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	immutable := func(path string) *field.Error {
+		return field.Invalid(field.NewPath("spec").Child(path), map[string]interface{}{"app": "a"}, "field is immutable")
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		fields []string
+	}{
+		{
+			name:   "immutable field",
+			err:    apierrors.NewInvalid(kind, "a", field.ErrorList{immutable("selector")}),
+			fields: []string{"spec.selector"},
+		},
+		{
+			name: "mixed causes",
+			err: apierrors.NewInvalid(kind, "a", field.ErrorList{
+				immutable("selector"),
+				field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+				field.Required(field.NewPath("spec", "template"), ""),
+			}),
+			fields: []string{"spec.selector"},
+		},
+		{
+			name: "multiple immutable fields",
+			err: apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, "a", field.ErrorList{
+				field.Invalid(field.NewPath("spec", "clusterIP"), "10.0.0.2", "field is immutable"),
+				field.Invalid(field.NewPath("spec", "ipFamily"), "IPv6", "field is immutable"),
+			}),
+			fields: []string{"spec.clusterIP", "spec.ipFamily"},
+		},
+		{
+			name: "no immutable fields",
+			err: apierrors.NewInvalid(kind, "a", field.ErrorList{
+				field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+			}),
+			fields: nil,
+		},
+		{
+			name:   "conflict",
+			err:    apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "a", errors.New("the object has been modified")),
+			fields: nil,
+		},
+		{
+			name:   "non status error",
+			err:    errors.New("field is immutable"),
+			fields: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if fields := immutableFields(test.err); !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("expected fields %v, got %v", test.fields, fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	kclient "github.com/cludden/benthos-kubernetes/client"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// principal identifies the user and groups impersonated when writing a
+// message
+type principal struct {
+	user   string
+	groups []string
+}
+
+// key returns a string that uniquely identifies the principal
+func (p principal) key() string {
+	return p.user + "\x00" + strings.Join(p.groups, "\x00")
+}
+
+type principalContextKey struct{}
+
+// withPrincipal returns a context under which API calls made by the output
+// impersonate the given principal
+func withPrincipal(ctx context.Context, p principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// principalFrom returns the principal impersonated under the given context,
+// if any
+func principalFrom(ctx context.Context) (principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(principal)
+	return p, ok
+}
+
+// resolvePrincipal resolves the principal impersonated when writing a message
+// part, returning false if the impersonated user resolves to an empty string
+func (k *Kubernetes) resolvePrincipal(index int, msg types.Message) (principal, bool) {
+	p := principal{user: k.impersonateUser.String(index, msg)}
+	if p.user == "" {
+		return p, false
+	}
+	for _, f := range k.impersonateGroups {
+		if g := f.String(index, msg); g != "" {
+			p.groups = append(p.groups, g)
+		}
+	}
+	return p, true
+}
+
+// clientset returns the typed clientset used for operations that are not
+// supported by the controller-runtime client (e.g. evictions), impersonating
+// the principal of the given context, if any
+func (k *Kubernetes) clientset(ctx context.Context) (kubernetes.Interface, error) {
+	if p, ok := principalFrom(ctx); ok && k.impersonation != nil {
+		return k.impersonation.clientset(p)
+	}
+	return k.resource.Clientset()
+}
+
+//------------------------------------------------------------------------------
+
+// impersonatingClient is a client that performs API calls as the principal of
+// the request context, using a client cloned from the shared rest config for
+// each distinct principal. Calls made without a principal use the shared
+// client.
+type impersonatingClient struct {
+	client.Client
+	resource *kclient.Resource
+
+	mut     sync.Mutex
+	clients map[string]*impersonatedClient
+}
+
+// impersonatedClient contains the clients of a single principal
+type impersonatedClient struct {
+	config    *rest.Config
+	client    client.Client
+	clientset kubernetes.Interface
+}
+
+func newImpersonatingClient(c client.Client, res *kclient.Resource) *impersonatingClient {
+	return &impersonatingClient{
+		Client:   c,
+		resource: res,
+		clients:  map[string]*impersonatedClient{},
+	}
+}
+
+// get returns the cached clients of a principal, creating them on first use
+func (c *impersonatingClient) get(p principal) (*impersonatedClient, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if ic, ok := c.clients[p.key()]; ok {
+		return ic, nil
+	}
+	rc, err := c.resource.RestConfig()
+	if err != nil {
+		return nil, err
+	}
+	rc.Impersonate = rest.ImpersonationConfig{
+		UserName: p.user,
+		Groups:   p.groups,
+	}
+	scheme, err := c.resource.Scheme()
+	if err != nil {
+		return nil, err
+	}
+	mapper, err := c.resource.Mapper()
+	if err != nil {
+		return nil, err
+	}
+	ic := &impersonatedClient{config: rc}
+	if ic.client, err = client.New(rc, client.Options{Scheme: scheme, Mapper: mapper}); err != nil {
+		return nil, fmt.Errorf("error initializing client for %s: %v", p.user, err)
+	}
+	c.clients[p.key()] = ic
+	return ic, nil
+}
+
+// clientFor returns the client used for API calls made under the given
+// context
+func (c *impersonatingClient) clientFor(ctx context.Context) (client.Client, error) {
+	p, ok := principalFrom(ctx)
+	if !ok {
+		return c.Client, nil
+	}
+	ic, err := c.get(p)
+	if err != nil {
+		return nil, err
+	}
+	return ic.client, nil
+}
+
+// clientset returns the typed clientset of a principal, creating it on first
+// use
+func (c *impersonatingClient) clientset(p principal) (kubernetes.Interface, error) {
+	ic, err := c.get(p)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if ic.clientset == nil {
+		if ic.clientset, err = kubernetes.NewForConfig(ic.config); err != nil {
+			return nil, fmt.Errorf("error initializing clientset for %s: %v", p.user, err)
+		}
+	}
+	return ic.clientset, nil
+}
+
+func (c *impersonatingClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	cl, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.Get(ctx, key, obj)
+}
+
+func (c *impersonatingClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	cl, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.List(ctx, list, opts...)
+}
+
+func (c *impersonatingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	cl, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.Create(ctx, obj, opts...)
+}
+
+func (c *impersonatingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	cl, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.Delete(ctx, obj, opts...)
+}
+
+func (c *impersonatingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	cl, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.Update(ctx, obj, opts...)
+}
+
+func (c *impersonatingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	cl, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *impersonatingClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	cl, err := c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *impersonatingClient) Status() client.StatusWriter {
+	return &impersonatingStatusWriter{c: c}
+}
+
+type impersonatingStatusWriter struct {
+	c *impersonatingClient
+}
+
+func (w *impersonatingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	cl, err := w.c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.Status().Update(ctx, obj, opts...)
+}
+
+func (w *impersonatingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	cl, err := w.c.clientFor(ctx)
+	if err != nil {
+		return err
+	}
+	return cl.Status().Patch(ctx, obj, patch, opts...)
+}
@@ -1,20 +1,36 @@
 package output
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message/roundtrip"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	kclient "github.com/cludden/benthos-kubernetes/client"
+	"github.com/cludden/benthos-kubernetes/render"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 func init() {
@@ -42,18 +58,89 @@ This plugin creates, updates, or deletes kubernetes object.`,
 
 // KubernetesConfig defines runtime configuration for a kubernetes output
 type KubernetesConfig struct {
+	kclient.Config      `json:",inline" yaml:",inline"`
+	AddFinalizer        string                     `json:"add_finalizer" yaml:"add_finalizer"`
+	Annotations         map[string]string          `json:"annotations" yaml:"annotations"`
+	Client              string                     `json:"client" yaml:"client"`
+	Check               string                     `json:"check" yaml:"check"`
+	CreateNamespace     CreateNamespaceConfig      `json:"create_namespace" yaml:"create_namespace"`
 	DeletionPropagation metav1.DeletionPropagation `json:"deletion_propagation" yaml:"deletion_propagation"`
+	EvictionTimeout     string                     `json:"eviction_timeout" yaml:"eviction_timeout"`
+	FieldManager        string                     `json:"field_manager" yaml:"field_manager"`
+	Format              string                     `json:"format" yaml:"format"`
+	ForceConflicts      bool                       `json:"force_conflicts" yaml:"force_conflicts"`
+	IgnoreAlreadyExists bool                       `json:"ignore_already_exists" yaml:"ignore_already_exists"`
+	ImpersonateGroups   []string                   `json:"impersonate_groups" yaml:"impersonate_groups"`
+	ImpersonateUser     string                     `json:"impersonate_user" yaml:"impersonate_user"`
+	LabelSelector       string                     `json:"label_selector" yaml:"label_selector"`
+	Labels              map[string]string          `json:"labels" yaml:"labels"`
 	MaxInFlight         int                        `json:"max_in_flight" yaml:"max_in_flight"`
+	MergeStrategy       string                     `json:"merge_strategy" yaml:"merge_strategy"`
+	MigrateFieldManager bool                       `json:"migrate_field_manager" yaml:"migrate_field_manager"`
+	Mode                string                     `json:"mode" yaml:"mode"`
+	OnImmutable         string                     `json:"on_immutable" yaml:"on_immutable"`
+	RateLimit           string                     `json:"rate_limit" yaml:"rate_limit"`
+	RecreateTimeout     string                     `json:"recreate_timeout" yaml:"recreate_timeout"`
+	RemoveFinalizer     string                     `json:"remove_finalizer" yaml:"remove_finalizer"`
+	Render              render.Config              `json:"render" yaml:"render"`
+	ResourceVersion     string                     `json:"resource_version" yaml:"resource_version"`
+	ReturnObject        bool                       `json:"return_object" yaml:"return_object"`
+	SplitDocuments      bool                       `json:"split_documents" yaml:"split_documents"`
+	StrictVersion       bool                       `json:"strict_version" yaml:"strict_version"`
+	Target              TargetConfig               `json:"target" yaml:"target"`
+	WaitForDeletion     WaitForDeletionConfig      `json:"wait_for_deletion" yaml:"wait_for_deletion"`
 }
 
 // NewKubernetesConfig returns a new KubernetesConfig value with sensible defaults
 func NewKubernetesConfig() interface{} {
 	return &KubernetesConfig{
+		Config:              kclient.NewConfig(),
+		Annotations:         map[string]string{},
+		CreateNamespace:     NewCreateNamespaceConfig(),
 		DeletionPropagation: metav1.DeletePropagationBackground,
+		EvictionTimeout:     "5m",
+		FieldManager:        "benthos",
+		Format:              FormatAuto,
+		ImpersonateGroups:   []string{},
+		Labels:              map[string]string{},
 		MaxInFlight:         1,
+		MergeStrategy:       MergeStrategyReplace,
+		Mode:                ModeAuto,
+		OnImmutable:         OnImmutableError,
+		RecreateTimeout:     "5m",
+		Render:              render.NewConfig(),
+		Target:              NewTargetConfig(),
+		WaitForDeletion:     NewWaitForDeletionConfig(),
 	}
 }
 
+// Supported output modes
+const (
+	ModeApply            = "apply"
+	ModeApplyMetadata    = "apply_metadata"
+	ModeAuto             = "auto"
+	ModeClientApply      = "client_apply"
+	ModeCreate           = "create"
+	ModeDelete           = "delete"
+	ModeDeleteCollection = "delete_collection"
+	ModeEvict            = "evict"
+	ModeFinalize         = "finalize"
+	ModeUpdate           = "update"
+)
+
+// eviction retry backoff bounds
+const (
+	evictionInitialInterval = time.Second
+	evictionMaxInterval     = 30 * time.Second
+)
+
+// Supported body formats
+const (
+	FormatAuto = "auto"
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
 //------------------------------------------------------------------------------
 
 // NewKubernetes creates a new kubernetes plugin output type.
@@ -85,12 +172,52 @@ func NewKubernetes(
 
 // Kubernetes output creates, updates, or deletes k8s objects
 type Kubernetes struct {
-	client client.Client
-
-	deletionPropagation metav1.DeletionPropagation
-
-	log   log.Modular
-	stats metrics.Type
+	client       client.Client
+	resource     *kclient.Resource
+	scheme       *runtime.Scheme
+	clientConfig kclient.Config
+	clientName   string
+	mgr          types.Manager
+
+	addFinalizer         bloblang.Field
+	annotations          map[string]bloblang.Field
+	check                bloblang.Mapping
+	createNamespace      bool
+	labelSelector        bloblang.Field
+	labels               map[string]bloblang.Field
+	deletionPropagation  metav1.DeletionPropagation
+	evictionTimeout      time.Duration
+	fieldManager         string
+	format               string
+	forceConflicts       bool
+	ignoreAlreadyExists  bool
+	impersonateGroups    []bloblang.Field
+	impersonateUser      bloblang.Field
+	impersonation        *impersonatingClient
+	mergeStrategy        string
+	migrateFieldManager  bool
+	mode                 string
+	namespaceAnnotations map[string]bloblang.Field
+	namespaceLabels      map[string]bloblang.Field
+	onImmutable          string
+	rateLimit            types.RateLimit
+	recreateTimeout      time.Duration
+	removeFinalizer      bloblang.Field
+	render               *render.Renderer
+	resourceVersion      bloblang.Field
+	returnObject         bool
+	splitDocuments       bool
+	strictVersion        bool
+	target               *targetFields
+	waitForDeletionConf  WaitForDeletionConfig
+	waitTimeout          time.Duration
+
+	log       log.Modular
+	stats     metrics.Type
+	mErrors   *kclient.ErrorCounter
+	mLimited  metrics.StatCounter
+	mLimitFor metrics.StatCounter
+	mLimitErr metrics.StatCounter
 
 	connMutex sync.Mutex
 }
@@ -103,15 +230,162 @@ func NewKubernetesWriter(
 	stats metrics.Type,
 ) (*Kubernetes, error) {
 	k := &Kubernetes{
+		clientConfig:        conf.Config,
+		clientName:          conf.Client,
+		createNamespace:     conf.CreateNamespace.Enabled,
+		mgr:                 mgr,
 		deletionPropagation: conf.DeletionPropagation,
+		fieldManager:        conf.FieldManager,
+		format:              conf.Format,
+		forceConflicts:      conf.ForceConflicts,
+		ignoreAlreadyExists: conf.IgnoreAlreadyExists,
+		mergeStrategy:       conf.MergeStrategy,
+		migrateFieldManager: conf.MigrateFieldManager,
+		mode:                conf.Mode,
+		onImmutable:         conf.OnImmutable,
+		returnObject:        conf.ReturnObject,
+		splitDocuments:      conf.SplitDocuments,
+		strictVersion:       conf.StrictVersion,
+		waitForDeletionConf: conf.WaitForDeletion,
 		log:                 log,
 		stats:               stats,
+		mErrors:             kclient.NewErrorCounter(stats),
+		mLimited:            stats.GetCounter("rate_limit.count"),
+		mLimitFor:           stats.GetCounter("rate_limit.total_ms"),
+		mLimitErr:           stats.GetCounter("rate_limit.error"),
 	}
 	switch k.deletionPropagation {
 	case metav1.DeletePropagationBackground, metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan:
 	default:
 		return nil, fmt.Errorf("invalid deletion propagation policy: %s", k.deletionPropagation)
 	}
+	switch k.mode {
+	case ModeApply, ModeApplyMetadata, ModeAuto, ModeClientApply, ModeCreate, ModeDelete, ModeDeleteCollection, ModeEvict, ModeFinalize, ModeUpdate:
+	default:
+		return nil, fmt.Errorf("invalid mode: %s", k.mode)
+	}
+	switch k.format {
+	case FormatAuto, FormatJSON, FormatYAML:
+	default:
+		return nil, fmt.Errorf("invalid format: %s", k.format)
+	}
+	switch k.mergeStrategy {
+	case MergeStrategyMerge, MergeStrategyReplace, MergeStrategyStrategic:
+	default:
+		return nil, fmt.Errorf("invalid merge_strategy: %s", k.mergeStrategy)
+	}
+	switch k.onImmutable {
+	case OnImmutableError, OnImmutableRecreate, OnImmutableSkip:
+	default:
+		return nil, fmt.Errorf("invalid on_immutable: %s", k.onImmutable)
+	}
+	// recreating an object from a partial body would drop its other fields
+	if k.onImmutable == OnImmutableRecreate && k.mergeStrategy != MergeStrategyReplace {
+		return nil, fmt.Errorf("on_immutable %s requires merge_strategy %s", OnImmutableRecreate, MergeStrategyReplace)
+	}
+	if (k.mode == ModeApply || k.mode == ModeApplyMetadata) && k.fieldManager == "" {
+		return nil, fmt.Errorf("field_manager is required when using %s mode", k.mode)
+	}
+	if k.migrateFieldManager && k.mode != ModeApply {
+		return nil, errors.New("migrate_field_manager requires apply mode")
+	}
+	if conf.RateLimit != "" {
+		rl, err := mgr.GetRateLimit(conf.RateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain rate limit resource: %v", err)
+		}
+		k.rateLimit = rl
+	}
+	if conf.EvictionTimeout != "" {
+		timeout, err := time.ParseDuration(conf.EvictionTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing eviction_timeout: %v", err)
+		}
+		k.evictionTimeout = timeout
+	}
+	if conf.WaitForDeletion.Timeout != "" {
+		timeout, err := time.ParseDuration(conf.WaitForDeletion.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing wait_for_deletion timeout: %v", err)
+		}
+		k.waitTimeout = timeout
+	}
+	if conf.RecreateTimeout != "" {
+		timeout, err := time.ParseDuration(conf.RecreateTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing recreate_timeout: %v", err)
+		}
+		k.recreateTimeout = timeout
+	}
+	var err error
+	if k.annotations, err = parseFields(conf.Annotations); err != nil {
+		return nil, fmt.Errorf("error parsing annotations: %v", err)
+	}
+	if k.labels, err = parseFields(conf.Labels); err != nil {
+		return nil, fmt.Errorf("error parsing labels: %v", err)
+	}
+	if k.namespaceAnnotations, err = parseFields(conf.CreateNamespace.Annotations); err != nil {
+		return nil, fmt.Errorf("error parsing create_namespace annotations: %v", err)
+	}
+	if k.namespaceLabels, err = parseFields(conf.CreateNamespace.Labels); err != nil {
+		return nil, fmt.Errorf("error parsing create_namespace labels: %v", err)
+	}
+	if conf.AddFinalizer != "" {
+		if k.addFinalizer, err = bloblang.NewField(conf.AddFinalizer); err != nil {
+			return nil, fmt.Errorf("error parsing add_finalizer: %v", err)
+		}
+	}
+	if conf.RemoveFinalizer != "" {
+		if k.removeFinalizer, err = bloblang.NewField(conf.RemoveFinalizer); err != nil {
+			return nil, fmt.Errorf("error parsing remove_finalizer: %v", err)
+		}
+	}
+	if k.mode == ModeFinalize && k.addFinalizer == nil && k.removeFinalizer == nil {
+		return nil, errors.New("add_finalizer or remove_finalizer is required when using finalize mode")
+	}
+	if conf.LabelSelector != "" {
+		if k.labelSelector, err = bloblang.NewField(conf.LabelSelector); err != nil {
+			return nil, fmt.Errorf("error parsing label_selector: %v", err)
+		}
+	}
+	if k.mode == ModeDeleteCollection && k.labelSelector == nil {
+		return nil, errors.New("label_selector is required when using delete_collection mode")
+	}
+	if conf.ResourceVersion != "" {
+		f, err := bloblang.NewField(conf.ResourceVersion)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing resource_version: %v", err)
+		}
+		k.resourceVersion = f
+	}
+	if conf.Check != "" {
+		m, err := bloblang.NewMapping(conf.Check)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing check: %v", err)
+		}
+		k.check = m
+	}
+	if conf.ImpersonateUser != "" {
+		if k.impersonateUser, err = bloblang.NewField(conf.ImpersonateUser); err != nil {
+			return nil, fmt.Errorf("error parsing impersonate_user: %v", err)
+		}
+	}
+	for i, g := range conf.ImpersonateGroups {
+		f, err := bloblang.NewField(g)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing impersonate_groups[%d]: %v", i, err)
+		}
+		k.impersonateGroups = append(k.impersonateGroups, f)
+	}
+	if len(k.impersonateGroups) > 0 && k.impersonateUser == nil {
+		return nil, errors.New("impersonate_groups requires impersonate_user")
+	}
+	if k.render, err = render.New(conf.Render); err != nil {
+		return nil, err
+	}
+	if k.target, err = newTargetFields(conf.Target); err != nil {
+		return nil, err
+	}
 	return k, nil
 }
 
@@ -132,12 +406,29 @@ func (k *Kubernetes) Connect() error {
 	}
 
 	// initalize controller manager
-	c, err := client.New(config.GetConfigOrDie(), client.Options{})
+	res, err := kclient.GetResource(k.mgr, k.clientName, "output", k.clientConfig, k.log, k.stats)
+	if err != nil {
+		return err
+	}
+	c, err := res.Client()
+	if err != nil {
+		return err
+	}
+	scheme, err := res.Scheme()
 	if err != nil {
-		return fmt.Errorf("error initializing controller manager: %v", err)
+		return err
 	}
+	k.resource = res
+	k.scheme = scheme
 	k.log.Infoln("Writing objects to kubernetes.")
-	k.client = c
+	if k.impersonateUser != nil {
+		k.impersonation = newImpersonatingClient(c, res)
+		c = k.impersonation
+	}
+	k.client = kclient.NewInstrumentedClient(c, k.mErrors)
+	if k.rateLimit != nil {
+		k.client = &rateLimitedClient{Client: k.client, wait: k.waitForAccess}
+	}
 
 	return nil
 }
@@ -155,45 +446,555 @@ func (k *Kubernetes) WriteWithContext(ctx context.Context, msg types.Message) er
 		return types.ErrNotConnected
 	}
 
-	return msg.Iter(func(i int, p types.Part) error {
-		var u unstructured.Unstructured
-		if err := u.UnmarshalJSON(p.Get()); err != nil {
-			return fmt.Errorf("error parsing object: %v", err)
+	err := msg.Iter(func(i int, p types.Part) error {
+		// skip parts that fail the configured check without calling the api
+		if k.check != nil {
+			ok, err := k.check.QueryPart(i, msg)
+			if err != nil {
+				return fmt.Errorf("error evaluating check: %v", err)
+			}
+			if !ok {
+				p.Metadata().Set("operation", "skipped")
+				return nil
+			}
+		}
+
+		// render the manifest from the message contents if configured
+		body := p.Get()
+		if k.render != nil {
+			var err error
+			if body, err = k.render.Render(i, msg); err != nil {
+				return err
+			}
+		}
+
+		objects, err := k.parseObjects(body)
+		if err != nil {
+			return err
 		}
 
-		switch {
-		case p.Metadata().Get("deleted") != "":
-			var opts []client.DeleteOption
+		// perform api calls as the principal resolved for the message
+		ctx := ctx
+		if k.impersonateUser != nil {
+			if p, ok := k.resolvePrincipal(i, msg); ok {
+				ctx = withPrincipal(ctx, p)
+			}
+		}
+
+		if !k.splitDocuments {
+			if _, err := k.writeObject(ctx, i, msg, objects[0]); err != nil {
+				return err
+			}
+		} else if err := k.writeDocuments(ctx, i, msg, objects); err != nil {
+			return err
+		}
 
-			policy := k.deletionPropagation
-			if msgPolicy := metav1.DeletionPropagation(p.Metadata().Get("deletion_propagation")); string(msgPolicy) != "" {
-				switch msgPolicy {
-				case metav1.DeletePropagationBackground, metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan:
-					policy = msgPolicy
-				default:
-					return fmt.Errorf("invalid deletion propagation policy: %s", msgPolicy)
+		// replace message contents with the object(s) returned by the server
+		if k.returnObject {
+			var b []byte
+			if k.splitDocuments {
+				items := make([]interface{}, len(objects))
+				for j, u := range objects {
+					items[j] = u.Object
 				}
+				b, err = json.Marshal(items)
+			} else {
+				b, err = objects[0].MarshalJSON()
+			}
+			if err != nil {
+				return fmt.Errorf("error marshalling returned object: %v", err)
+			}
+			p.Set(b)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// expose returned objects to synchronous response consumers (e.g. the
+	// kubernetes input result mappings)
+	if k.returnObject {
+		if err := roundtrip.SetAsResponse(msg); err != nil && err != roundtrip.ErrNoStore {
+			return fmt.Errorf("error setting returned objects as response: %v", err)
+		}
+	}
+	return nil
+}
+
+// parseObjects parses the kubernetes object(s) contained in a message body
+func (k *Kubernetes) parseObjects(b []byte) ([]*unstructured.Unstructured, error) {
+	if !k.splitDocuments {
+		u, err := k.decodeObject(b)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing object: %v", err)
+		}
+		if u == nil {
+			return nil, errors.New("error parsing object: empty document")
+		}
+		return []*unstructured.Unstructured{u}, nil
+	}
+
+	var objects []*unstructured.Unstructured
+	reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	line := 1
+	for i := 0; ; i++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading document %d: %v", i, err)
+		}
+		start := line
+		line += bytes.Count(doc, []byte("\n")) + 1
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		u, err := k.decodeObject(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing document %d (starting at line %d): %v", i, start, err)
+		}
+		if u == nil {
+			continue
+		}
+		objects = append(objects, u)
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("error parsing object: no documents found")
+	}
+	return objects, nil
+}
+
+// decodeObject decodes a single JSON or YAML document according to the
+// configured format, returning nil if the document is empty
+func (k *Kubernetes) decodeObject(b []byte) (*unstructured.Unstructured, error) {
+	format := k.format
+	if format == FormatAuto {
+		format = FormatYAML
+		if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+			format = FormatJSON
+		}
+	}
+
+	if format == FormatYAML {
+		var err error
+		if b, err = sigsyaml.YAMLToJSON(b); err != nil {
+			return nil, err
+		}
+		if string(b) == "null" {
+			return nil, nil
+		}
+	}
+
+	// the identity of objects is optional when resolved from the target
+	// fields, such that the body may contain only a fragment of the object
+	var u unstructured.Unstructured
+	if k.target != nil {
+		if err := json.Unmarshal(b, &u.Object); err != nil {
+			return nil, err
+		}
+		if u.Object == nil {
+			return nil, errors.New("document must be an object")
+		}
+		return &u, nil
+	}
+	if err := u.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// documentResult describes the result of writing a single document of a
+// message when split_documents is enabled
+type documentResult struct {
+	Index     int    `json:"index"`
+	Object    string `json:"object"`
+	Operation string `json:"operation,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// writeDocuments writes each document of a message, attempting every document
+// even if some fail, and records the result of each document in a
+// document_results metadata field
+func (k *Kubernetes) writeDocuments(ctx context.Context, index int, msg types.Message, objects []*unstructured.Unstructured) error {
+	p := msg.Get(index)
+	results := make([]documentResult, len(objects))
+	var failed []string
+	for j, u := range objects {
+		results[j] = documentResult{Index: j, Object: objectID(u)}
+
+		// operations overridden by the write (e.g. exists or skipped) are
+		// reported via metadata, which is reset for each document
+		p.Metadata().Delete("operation")
+		mode, err := k.writeObject(ctx, index, msg, u)
+		if err != nil {
+			results[j].Error = err.Error()
+			failed = append(failed, fmt.Sprintf("document %d (%s): %v", j, results[j].Object, err))
+			continue
+		}
+		if results[j].Operation = p.Metadata().Get("operation"); results[j].Operation == "" {
+			results[j].Operation = mode
+		}
+	}
+
+	if b, err := json.Marshal(results); err == nil {
+		p.Metadata().Set("document_results", string(b))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to write %d of %d documents: %s", len(failed), len(objects), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// writeObject creates, updates, applies, or deletes a single object, returning
+// the mode resolved for the object
+func (k *Kubernetes) writeObject(ctx context.Context, index int, msg types.Message, u *unstructured.Unstructured) (string, error) {
+	p := msg.Get(index)
+	if err := k.target.apply(index, msg, u); err != nil {
+		return "", fmt.Errorf("error resolving target: %v", err)
+	}
+
+	mode := k.mode
+	switch {
+	case (mode == ModeAuto || mode == ModeApply || mode == ModeClientApply) && p.Metadata().Get("deleted") != "":
+		mode = ModeDelete
+	case mode == ModeAuto && string(u.GetUID()) != "":
+		mode = ModeUpdate
+	case mode == ModeAuto:
+		mode = ModeCreate
+	}
+
+	// write objects using the version served by the api server when the
+	// version of the object is not served, unless strict_version is set
+	if !k.strictVersion {
+		gvk, err := k.resource.ResolveGVK(u.GroupVersionKind(), false)
+		if err != nil {
+			return mode, fmt.Errorf("error resolving object version: %v", err)
+		}
+		u.SetGroupVersionKind(gvk)
+	}
+
+	// merge configured labels, annotations, and finalizers onto written
+	// objects
+	if mode != ModeDelete && mode != ModeDeleteCollection && mode != ModeEvict && mode != ModeFinalize {
+		if len(k.labels) > 0 {
+			u.SetLabels(mergeFields(u.GetLabels(), k.labels, index, msg))
+		}
+		if len(k.annotations) > 0 {
+			u.SetAnnotations(mergeFields(u.GetAnnotations(), k.annotations, index, msg))
+		}
+		if k.addFinalizer != nil {
+			if f := k.addFinalizer.String(index, msg); f != "" {
+				u.SetFinalizers(addFinalizer(u.GetFinalizers(), f))
 			}
+		}
+	}
+
+	switch mode {
+	case ModeDelete:
+		var opts []client.DeleteOption
+
+		policy, err := k.propagationPolicy(p)
+		if err != nil {
+			return mode, err
+		}
+
+		opts = append(opts, &client.DeleteOptions{
+			PropagationPolicy: &policy,
+		})
 
-			opts = append(opts, &client.DeleteOptions{
-				PropagationPolicy: &policy,
+		uid := u.GetUID()
+		if err := k.client.Delete(ctx, u, opts...); err != nil {
+			return mode, fmt.Errorf("error deleting object: %v", err)
+		}
+
+		// block until finalizers have run and the object is removed
+		if k.waitForDeletionConf.Enabled {
+			if err := k.waitForDeletion(ctx, u, uid, k.waitTimeout); err != nil {
+				return mode, fmt.Errorf("error deleting object: %v", err)
+			}
+		}
+	case ModeDeleteCollection:
+		policy, err := k.propagationPolicy(p)
+		if err != nil {
+			return mode, err
+		}
+		raw := k.labelSelector.String(index, msg)
+		selector, err := labels.Parse(raw)
+		if err != nil {
+			return mode, fmt.Errorf("invalid label_selector %q: %v", raw, err)
+		}
+		// refuse to delete every object of a kind due to an empty selector
+		if selector.Empty() {
+			return mode, errors.New("error deleting collection: label_selector must not be empty")
+		}
+		count, err := k.deleteCollection(ctx, u, selector, policy)
+		p.Metadata().Set("deleted_count", strconv.Itoa(count))
+		if err != nil {
+			return mode, fmt.Errorf("error deleting collection: %v", err)
+		}
+	case ModeEvict:
+		if err := k.evict(ctx, u); err != nil {
+			return mode, fmt.Errorf("error evicting object: %v", err)
+		}
+	case ModeFinalize:
+		var add, remove string
+		if k.addFinalizer != nil {
+			add = k.addFinalizer.String(index, msg)
+		}
+		if k.removeFinalizer != nil {
+			remove = k.removeFinalizer.String(index, msg)
+		}
+		if err := k.finalize(ctx, u, add, remove); err != nil {
+			return mode, fmt.Errorf("error updating finalizers: %v", err)
+		}
+	case ModeUpdate:
+		// require the stored object to match the expected resource version,
+		// such that the update fails with a conflict if it has changed
+		if k.resourceVersion != nil {
+			if rv := k.resourceVersion.String(index, msg); rv != "" {
+				u.SetResourceVersion(rv)
+			}
+		}
+		var err error
+		if k.mergeStrategy != MergeStrategyReplace {
+			err = k.mergeUpdate(ctx, u)
+		} else {
+			err = k.client.Update(ctx, u)
+		}
+		if err != nil {
+			err = k.handleImmutable(ctx, p, u, err, func() error {
+				return k.client.Create(ctx, u)
 			})
+		}
+		if err != nil {
+			return mode, fmt.Errorf("error updating object: %v", err)
+		}
+	case ModeApplyMetadata:
+		opts := []client.PatchOption{client.FieldOwner(k.fieldManager)}
+		if k.forceConflicts {
+			opts = append(opts, client.ForceOwnership)
+		}
+		if err := k.applyMetadata(ctx, u, opts...); err != nil {
+			return mode, k.applyError(p, u, err)
+		}
+	case ModeClientApply:
+		write := func() error {
+			return k.clientApply(ctx, u)
+		}
+		err := k.withNamespace(ctx, index, msg, u, write)
+		if err != nil {
+			err = k.handleImmutable(ctx, p, u, err, write)
+		}
+		if err != nil {
+			return mode, fmt.Errorf("error applying object: %v", err)
+		}
+	case ModeApply:
+		// objects still managed by client-side apply are migrated by forcing
+		// ownership of the applied fields and then removing the annotation
+		var migrate bool
+		if k.migrateFieldManager {
+			var err error
+			if migrate, err = k.managedByClientApply(ctx, u); err != nil {
+				return mode, fmt.Errorf("error getting object: %v", err)
+			}
+		}
 
-			if err := k.client.Delete(ctx, &u, opts...); err != nil {
-				return fmt.Errorf("error deleting object: %v", err)
+		opts := []client.PatchOption{client.FieldOwner(k.fieldManager)}
+		if k.forceConflicts || migrate {
+			opts = append(opts, client.ForceOwnership)
+		}
+		u.SetManagedFields(nil)
+		write := func() error {
+			return k.client.Patch(ctx, u, client.Apply, opts...)
+		}
+		err := k.withNamespace(ctx, index, msg, u, write)
+		if err != nil {
+			err = k.handleImmutable(ctx, p, u, err, write)
+		}
+		if err != nil {
+			return mode, k.applyError(p, u, err)
+		}
+		if migrate {
+			if err := k.removeLastApplied(ctx, u); err != nil {
+				return mode, fmt.Errorf("error migrating field manager: %v", err)
 			}
-		case string(u.GetUID()) != "":
-			if err := k.client.Update(ctx, &u); err != nil {
-				return fmt.Errorf("error updating object: %v", err)
+			k.log.Infof("migrated %s from client-side apply to field manager %s", objectID(u), k.fieldManager)
+		}
+	default:
+		err := k.withNamespace(ctx, index, msg, u, func() error {
+			return k.client.Create(ctx, u)
+		})
+		if err != nil {
+			// treat existing objects as a successful no-op when configured
+			if k.ignoreAlreadyExists && apierrors.IsAlreadyExists(err) {
+				k.log.Debugf("object already exists: %s", objectID(u))
+				p.Metadata().Set("operation", "exists")
+				return mode, nil
 			}
+			return mode, fmt.Errorf("error creating object: %v", err)
+		}
+	}
+	return mode, nil
+}
+
+// propagationPolicy returns the deletion propagation policy for a message
+// part, preferring a valid deletion_propagation metadata value over the
+// configured policy
+func (k *Kubernetes) propagationPolicy(p types.Part) (metav1.DeletionPropagation, error) {
+	policy := k.deletionPropagation
+	if msgPolicy := metav1.DeletionPropagation(p.Metadata().Get("deletion_propagation")); string(msgPolicy) != "" {
+		switch msgPolicy {
+		case metav1.DeletePropagationBackground, metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan:
+			policy = msgPolicy
 		default:
-			if err := k.client.Create(ctx, &u); err != nil {
-				return fmt.Errorf("error creating object: %v", err)
-			}
+			return "", fmt.Errorf("invalid deletion propagation policy: %s", msgPolicy)
+		}
+	}
+	return policy, nil
+}
+
+// evict evicts a pod using the Eviction API, which honors pod disruption
+// budgets, retrying with capped exponential backoff while the eviction is
+// rejected
+func (k *Kubernetes) evict(ctx context.Context, u *unstructured.Unstructured) error {
+	if gvk := u.GroupVersionKind(); gvk.Group != "" || gvk.Kind != "Pod" {
+		return fmt.Errorf("unsupported kind for eviction: %s", gvk.String())
+	}
+
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      u.GetName(),
+			Namespace: u.GetNamespace(),
+		},
+	}
+	if k.evictionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, k.evictionTimeout)
+		defer cancel()
+	}
+
+	clientset, err := k.clientset(ctx)
+	if err != nil {
+		return err
+	}
+
+	interval := evictionInitialInterval
+	for {
+		if err := k.waitForAccess(ctx); err != nil {
+			return err
+		}
+		err := clientset.PolicyV1beta1().Evictions(u.GetNamespace()).Evict(ctx, eviction)
+		k.mErrors.Incr(err)
+		if err == nil || !apierrors.IsTooManyRequests(err) {
+			return err
 		}
+		k.log.Debugf("eviction of %s rejected by disruption budget, retrying in %s", objectID(u), interval)
 
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for disruption budget: %v", ctx.Err())
+		}
+		if interval *= 2; interval > evictionMaxInterval {
+			interval = evictionMaxInterval
+		}
+	}
+}
+
+// applyError returns the error of a failed server-side apply, describing any
+// conflicts with other field managers in both the error and an
+// apply_conflicts metadata field
+func (k *Kubernetes) applyError(p types.Part, u *unstructured.Unstructured, err error) error {
+	if conflicts := applyConflicts(err); len(conflicts) > 0 {
+		if b, jerr := json.Marshal(conflicts); jerr == nil {
+			p.Metadata().Set("apply_conflicts", string(b))
+		}
+		k.log.Warnf("apply conflicts for %s: %s", objectID(u), formatApplyConflicts(conflicts))
+		return fmt.Errorf("error applying object: conflicts with field managers: %s", formatApplyConflicts(conflicts))
+	}
+	return fmt.Errorf("error applying object: %v", err)
+}
+
+// applyConflict describes a field owned by another field manager that
+// conflicts with a server-side apply request
+type applyConflict struct {
+	Field   string `json:"field"`
+	Manager string `json:"manager"`
+}
+
+var conflictManagerRegexp = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+// applyConflicts parses the field manager conflicts from a server-side apply
+// error, returning nil if the error is not a conflict
+func applyConflicts(err error) []applyConflict {
+	if !apierrors.IsConflict(err) {
 		return nil
-	})
+	}
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return nil
+	}
+
+	var conflicts []applyConflict
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		c := applyConflict{Field: cause.Field, Manager: cause.Message}
+		if m := conflictManagerRegexp.FindStringSubmatch(cause.Message); len(m) == 2 {
+			c.Manager = m[1]
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts
+}
+
+// formatApplyConflicts returns a human readable summary of apply conflicts
+func formatApplyConflicts(conflicts []applyConflict) string {
+	parts := make([]string, len(conflicts))
+	for i, c := range conflicts {
+		parts[i] = fmt.Sprintf("%s (%s)", c.Field, c.Manager)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseFields parses a map of interpolated strings
+func parseFields(raw map[string]string) (map[string]bloblang.Field, error) {
+	fields := make(map[string]bloblang.Field, len(raw))
+	for k, v := range raw {
+		f, err := bloblang.NewField(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", k, err)
+		}
+		fields[k] = f
+	}
+	return fields, nil
+}
+
+// mergeFields resolves interpolated fields and merges them onto an existing
+// map, overriding existing keys while preserving all others
+func mergeFields(existing map[string]string, fields map[string]bloblang.Field, index int, msg types.Message) map[string]string {
+	merged := make(map[string]string, len(existing)+len(fields))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, f := range fields {
+		merged[k] = f.String(index, msg)
+	}
+	return merged
+}
+
+// objectID returns a human readable identifier for an object
+func objectID(u *unstructured.Unstructured) string {
+	id := u.GroupVersionKind().String()
+	if ns := u.GetNamespace(); ns != "" {
+		return fmt.Sprintf("%s %s/%s", id, ns, u.GetName())
+	}
+	return fmt.Sprintf("%s %s", id, u.GetName())
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
@@ -2,6 +2,7 @@ package output
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -11,9 +12,9 @@ import (
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/output"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	kclient "github.com/cludden/benthos-kubernetes/client"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 func init() {
@@ -41,16 +42,39 @@ This plugin updates kubernetes objects' status.`,
 
 // KubernetesStatusConfig defines runtime configuration for a kubernetes output
 type KubernetesStatusConfig struct {
-	MaxInFlight int `json:"max_in_flight" yaml:"max_in_flight"`
+	kclient.Config    `json:",inline" yaml:",inline"`
+	Client            string `json:"client" yaml:"client"`
+	FieldManager      string `json:"field_manager" yaml:"field_manager"`
+	ForceConflicts    bool   `json:"force_conflicts" yaml:"force_conflicts"`
+	MaxInFlight       int    `json:"max_in_flight" yaml:"max_in_flight"`
+	Mode              string `json:"mode" yaml:"mode"`
+	StatusSubresource string `json:"status_subresource" yaml:"status_subresource"`
 }
 
 // NewKubernetesStatusConfig returns a new KubernetesStatusConfig value with sensible defaults
 func NewKubernetesStatusConfig() interface{} {
 	return &KubernetesStatusConfig{
-		MaxInFlight: 1,
+		Config:            kclient.NewConfig(),
+		FieldManager:      "benthos-status",
+		MaxInFlight:       1,
+		Mode:              StatusModeUpdate,
+		StatusSubresource: StatusSubresourceAuto,
 	}
 }
 
+// Supported status output modes
+const (
+	StatusModeApply  = "apply"
+	StatusModeUpdate = "update"
+)
+
+// Supported status subresource routing options
+const (
+	StatusSubresourceAlways = "always"
+	StatusSubresourceAuto   = "auto"
+	StatusSubresourceNever  = "never"
+)
+
 //------------------------------------------------------------------------------
 
 // NewKubernetesStatus creates a new kubernetes plugin output type.
@@ -82,7 +106,16 @@ func NewKubernetesStatus(
 
 // KubernetesStatus output creates, updates, or deletes k8s objects
 type KubernetesStatus struct {
-	client client.Client
+	client       client.Client
+	resource     *kclient.Resource
+	clientConfig kclient.Config
+	clientName   string
+	mgr          types.Manager
+
+	fieldManager      string
+	forceConflicts    bool
+	mode              string
+	statusSubresource string
 
 	log   log.Modular
 	stats metrics.Type
@@ -98,8 +131,29 @@ func NewKubernetesStatusWriter(
 	stats metrics.Type,
 ) (*KubernetesStatus, error) {
 	k := &KubernetesStatus{
-		log:   log,
-		stats: stats,
+		clientConfig:      conf.Config,
+		clientName:        conf.Client,
+		mgr:               mgr,
+		fieldManager:      conf.FieldManager,
+		forceConflicts:    conf.ForceConflicts,
+		mode:              conf.Mode,
+		statusSubresource: conf.StatusSubresource,
+		log:               log,
+		stats:             stats,
+	}
+	switch k.mode {
+	case StatusModeApply:
+		if k.fieldManager == "" {
+			return nil, errors.New("field_manager is required when using apply mode")
+		}
+	case StatusModeUpdate:
+	default:
+		return nil, fmt.Errorf("invalid mode: %s", k.mode)
+	}
+	switch k.statusSubresource {
+	case StatusSubresourceAlways, StatusSubresourceAuto, StatusSubresourceNever:
+	default:
+		return nil, fmt.Errorf("invalid status_subresource: %s", k.statusSubresource)
 	}
 	return k, nil
 }
@@ -121,12 +175,17 @@ func (k *KubernetesStatus) Connect() error {
 	}
 
 	// initalize controller manager
-	c, err := client.New(config.GetConfigOrDie(), client.Options{})
+	res, err := kclient.GetResource(k.mgr, k.clientName, "output", k.clientConfig, k.log, k.stats)
 	if err != nil {
-		return fmt.Errorf("error initializing controller manager: %v", err)
+		return err
+	}
+	c, err := res.Client()
+	if err != nil {
+		return err
 	}
 	k.log.Infoln("Writing object status to kubernetes.")
 	k.client = c
+	k.resource = res
 
 	return nil
 }
@@ -150,13 +209,84 @@ func (k *KubernetesStatus) WriteWithContext(ctx context.Context, msg types.Messa
 			return fmt.Errorf("error parsing object: %v", err)
 		}
 
-		if err := k.client.Status().Update(ctx, &u); err != nil {
+		subresource, err := k.useStatusSubresource(&u)
+		if err != nil {
+			return err
+		}
+		if k.mode == StatusModeApply {
+			return k.applyStatus(ctx, p, &u, subresource)
+		}
+
+		if subresource {
+			err = k.client.Status().Update(ctx, &u)
+		} else {
+			err = k.client.Update(ctx, &u)
+		}
+		if err != nil {
 			return fmt.Errorf("error updating object status: %v", err)
 		}
 		return nil
 	})
 }
 
+// useStatusSubresource returns true if the status of the given object should
+// be written via the status subresource, which is detected using the
+// discovery api unless configured explicitly
+func (k *KubernetesStatus) useStatusSubresource(u *unstructured.Unstructured) (bool, error) {
+	switch k.statusSubresource {
+	case StatusSubresourceAlways:
+		return true, nil
+	case StatusSubresourceNever:
+		return false, nil
+	}
+	has, err := k.resource.HasStatusSubresource(u.GroupVersionKind())
+	if err != nil {
+		return false, fmt.Errorf("error detecting status subresource: %v", err)
+	}
+	return has, nil
+}
+
+// applyStatus applies the status of the given object using server-side apply
+// of the status subresource, or of the object itself when it has no status
+// subresource, such that only the status fields set by the message are owned
+// by the configured field manager
+func (k *KubernetesStatus) applyStatus(ctx context.Context, p types.Part, u *unstructured.Unstructured, subresource bool) error {
+	status, ok, err := unstructured.NestedFieldNoCopy(u.Object, "status")
+	if err != nil || !ok {
+		return errors.New("error applying object status: object has no status")
+	}
+
+	// the applied configuration only identifies the object and its status,
+	// as any other field would be owned by the field manager
+	applied := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": status,
+	}}
+	applied.SetGroupVersionKind(u.GroupVersionKind())
+	applied.SetNamespace(u.GetNamespace())
+	applied.SetName(u.GetName())
+
+	opts := []client.PatchOption{client.FieldOwner(k.fieldManager)}
+	if k.forceConflicts {
+		opts = append(opts, client.ForceOwnership)
+	}
+	if subresource {
+		err = k.client.Status().Patch(ctx, applied, client.Apply, opts...)
+	} else {
+		err = k.client.Patch(ctx, applied, client.Apply, opts...)
+	}
+	if err != nil {
+		if conflicts := applyConflicts(err); len(conflicts) > 0 {
+			if b, jerr := json.Marshal(conflicts); jerr == nil {
+				p.Metadata().Set("apply_conflicts", string(b))
+			}
+			k.log.Warnf("status apply conflicts for %s: %s", objectID(u), formatApplyConflicts(conflicts))
+			return fmt.Errorf("error applying object status: conflicts with field managers: %s", formatApplyConflicts(conflicts))
+		}
+		return fmt.Errorf("error applying object status: %v", err)
+	}
+	return nil
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (k *KubernetesStatus) CloseAsync() {
 }
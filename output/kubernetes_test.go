@@ -0,0 +1,214 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newApplyConflictError returns a conflict error as returned by the api server
+// for a server-side apply request that conflicts with other field managers
+func newApplyConflictError(causes ...metav1.StatusCause) error {
+	return apierrors.NewApplyConflict(causes, "Apply failed with 2 conflicts: conflicts with \"kubectl\"")
+}
+
+func TestApplyConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		conflicts []applyConflict
+	}{
+		{
+			name: "field manager conflicts",
+			err: newApplyConflictError(
+				metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "kubectl-client-side-apply" using apps/v1`,
+					Field:   ".spec.replicas",
+				},
+				metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "helm" using apps/v1 at 2020-06-01T00:00:00Z`,
+					Field:   ".spec.template.spec.containers[name=\"app\"].image",
+				},
+			),
+			conflicts: []applyConflict{
+				{Field: ".spec.replicas", Manager: "kubectl-client-side-apply"},
+				{Field: ".spec.template.spec.containers[name=\"app\"].image", Manager: "helm"},
+			},
+		},
+		{
+			name: "unrecognized message",
+			err: newApplyConflictError(metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: "conflicting manager",
+				Field:   ".data.key",
+			}),
+			conflicts: []applyConflict{
+				{Field: ".data.key", Manager: "conflicting manager"},
+			},
+		},
+		{
+			name: "other causes",
+			err: newApplyConflictError(metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Message: "invalid value",
+				Field:   ".spec.replicas",
+			}),
+			conflicts: nil,
+		},
+		{
+			name:      "optimistic lock conflict",
+			err:       apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "a", errors.New("the object has been modified")),
+			conflicts: nil,
+		},
+		{
+			name:      "not found",
+			err:       apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "a"),
+			conflicts: nil,
+		},
+		{
+			name:      "non status error",
+			err:       errors.New("connection refused"),
+			conflicts: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if conflicts := applyConflicts(test.err); !reflect.DeepEqual(conflicts, test.conflicts) {
+				t.Errorf("expected conflicts %v, got %v", test.conflicts, conflicts)
+			}
+		})
+	}
+}
+
+func TestFormatApplyConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		conflicts []applyConflict
+		expected  string
+	}{
+		{
+			name:      "none",
+			conflicts: nil,
+			expected:  "",
+		},
+		{
+			name:      "single",
+			conflicts: []applyConflict{{Field: ".spec.replicas", Manager: "kubectl"}},
+			expected:  ".spec.replicas (kubectl)",
+		},
+		{
+			name: "multiple",
+			conflicts: []applyConflict{
+				{Field: ".spec.replicas", Manager: "kubectl"},
+				{Field: ".metadata.labels.app", Manager: "helm"},
+			},
+			expected: ".spec.replicas (kubectl), .metadata.labels.app (helm)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if s := formatApplyConflicts(test.conflicts); s != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, s)
+			}
+		})
+	}
+}
+
+// createClient is a client that creates objects, failing with an already
+// exists error for objects with the given names
+type createClient struct {
+	client.Client
+	existing map[string]bool
+}
+
+func (c *createClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	u := obj.(*unstructured.Unstructured)
+	if c.existing[u.GetName()] {
+		return apierrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, u.GetName())
+	}
+	return nil
+}
+
+func TestWriteSplitDocuments(t *testing.T) {
+	k := &Kubernetes{
+		client:         &createClient{existing: map[string]bool{"b": true}},
+		format:         FormatYAML,
+		log:            log.Noop(),
+		mode:           ModeCreate,
+		splitDocuments: true,
+		strictVersion:  true,
+	}
+
+	msg := message.New([][]byte{[]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+  namespace: default
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: c
+  namespace: default
+`)})
+
+	err := k.WriteWithContext(context.Background(), msg)
+	if err == nil {
+		t.Fatal("expected error for failed document")
+	}
+	if !strings.Contains(err.Error(), "failed to write 1 of 3 documents") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var results []documentResult
+	if err := json.Unmarshal([]byte(msg.Get(0).Metadata().Get("document_results")), &results); err != nil {
+		t.Fatal(err)
+	}
+	expected := []documentResult{
+		{Index: 0, Object: "/v1, Kind=ConfigMap default/a", Operation: ModeCreate},
+		{Index: 1, Object: "/v1, Kind=ConfigMap default/b", Error: `error creating object: configmaps "b" already exists`},
+		{Index: 2, Object: "/v1, Kind=ConfigMap default/c", Operation: ModeCreate},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected results %+v, got %+v", expected, results)
+	}
+
+	// operations reported via metadata are attributed to their document
+	k.ignoreAlreadyExists = true
+	msg = message.New([][]byte{msg.Get(0).Get()})
+	if err := k.WriteWithContext(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	results = nil
+	if err := json.Unmarshal([]byte(msg.Get(0).Metadata().Get("document_results")), &results); err != nil {
+		t.Fatal(err)
+	}
+	for i, operation := range []string{ModeCreate, "exists", ModeCreate} {
+		if results[i].Operation != operation || results[i].Error != "" {
+			t.Errorf("expected document %d operation %s, got %+v", i, operation, results[i])
+		}
+	}
+}
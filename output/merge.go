@@ -0,0 +1,88 @@
+package output
+
+import (
+	"context"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Supported merge strategies
+const (
+	MergeStrategyMerge     = "merge"
+	MergeStrategyReplace   = "replace"
+	MergeStrategyStrategic = "strategic"
+)
+
+//------------------------------------------------------------------------------
+
+// mergeUpdate updates an object by merging the desired object onto the live
+// object using the configured merge strategy, such that the desired object
+// only needs to contain the fields being changed. Conflicts are retried
+// against the latest live object unless the desired object specifies the
+// expected resource version.
+func (k *Kubernetes) mergeUpdate(ctx context.Context, u *unstructured.Unstructured) error {
+	patch, err := u.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("error encoding object: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(u.GroupVersionKind())
+		key := client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}
+		if err := k.client.Get(ctx, key, current); err != nil {
+			return fmt.Errorf("error getting object: %v", err)
+		}
+
+		merged, err := k.mergeObject(current, patch)
+		if err != nil {
+			return fmt.Errorf("error merging object: %v", err)
+		}
+		if merged.GetResourceVersion() == "" {
+			merged.SetResourceVersion(current.GetResourceVersion())
+		}
+
+		err = k.client.Update(ctx, merged)
+		if err == nil {
+			u.Object = merged.Object
+			return nil
+		}
+		if !apierrors.IsConflict(err) || u.GetResourceVersion() != "" || attempt >= clientApplyMaxRetries {
+			return err
+		}
+		k.log.Debugf("conflict updating %s, retrying", objectID(u))
+	}
+}
+
+// mergeObject merges a patch onto the given object, using a strategic merge
+// patch for built-in types when configured, and a JSON merge patch otherwise
+func (k *Kubernetes) mergeObject(current *unstructured.Unstructured, patch []byte) (*unstructured.Unstructured, error) {
+	currentB, err := current.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var mergedB []byte
+	if obj, serr := k.scheme.New(current.GroupVersionKind()); serr == nil && k.mergeStrategy == MergeStrategyStrategic {
+		if _, ok := obj.(*unstructured.Unstructured); !ok {
+			mergedB, err = strategicpatch.StrategicMergePatch(currentB, patch, obj)
+		}
+	}
+	if mergedB == nil && err == nil {
+		mergedB, err = jsonpatch.MergePatch(currentB, patch)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &unstructured.Unstructured{}
+	if err := merged.UnmarshalJSON(mergedB); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// managedByClientApply returns true if the live object identified by the given
+// object carries the last-applied-configuration annotation maintained by
+// client-side apply, and therefore requires migration to server-side apply
+func (k *Kubernetes) managedByClientApply(ctx context.Context, u *unstructured.Unstructured) (bool, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(u.GroupVersionKind())
+	key := client.ObjectKey{Namespace: u.GetNamespace(), Name: u.GetName()}
+	if err := k.client.Get(ctx, key, live); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	_, ok := live.GetAnnotations()[corev1.LastAppliedConfigAnnotation]
+	return ok, nil
+}
+
+// removeLastApplied removes the last-applied-configuration annotation from the
+// given object using a JSON merge patch, such that client-side apply no longer
+// considers the object managed by it
+func (k *Kubernetes) removeLastApplied(ctx context.Context, u *unstructured.Unstructured) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				corev1.LastAppliedConfigAnnotation: nil,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return k.client.Patch(ctx, u, client.RawPatch(types.MergePatchType, patch))
+}
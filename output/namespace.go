@@ -0,0 +1,77 @@
+package output
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+//------------------------------------------------------------------------------
+
+// CreateNamespaceConfig defines runtime configuration for creating missing
+// namespaces
+type CreateNamespaceConfig struct {
+	Annotations map[string]string `json:"annotations" yaml:"annotations"`
+	Enabled     bool              `json:"enabled" yaml:"enabled"`
+	Labels      map[string]string `json:"labels" yaml:"labels"`
+}
+
+// NewCreateNamespaceConfig returns a CreateNamespaceConfig with default values
+func NewCreateNamespaceConfig() CreateNamespaceConfig {
+	return CreateNamespaceConfig{
+		Annotations: map[string]string{},
+		Labels:      map[string]string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// withNamespace invokes the given write, creating the namespace of the object
+// and retrying the write once if it fails because the namespace does not exist
+func (k *Kubernetes) withNamespace(ctx context.Context, index int, msg types.Message, u *unstructured.Unstructured, write func() error) error {
+	err := write()
+	if err == nil || !k.createNamespace || !isMissingNamespace(err, u.GetNamespace()) {
+		return err
+	}
+
+	k.log.Infof("creating missing namespace: %s", u.GetNamespace())
+	if err := k.ensureNamespace(ctx, index, msg, u.GetNamespace()); err != nil {
+		return fmt.Errorf("error creating namespace %s: %v", u.GetNamespace(), err)
+	}
+	return write()
+}
+
+// ensureNamespace creates a namespace with the configured labels and
+// annotations, succeeding if it already exists
+func (k *Kubernetes) ensureNamespace(ctx context.Context, index int, msg types.Message, name string) error {
+	ns := &corev1.Namespace{}
+	ns.SetName(name)
+	if len(k.namespaceLabels) > 0 {
+		ns.SetLabels(mergeFields(nil, k.namespaceLabels, index, msg))
+	}
+	if len(k.namespaceAnnotations) > 0 {
+		ns.SetAnnotations(mergeFields(nil, k.namespaceAnnotations, index, msg))
+	}
+	if err := k.client.Create(ctx, ns); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// isMissingNamespace returns true if the error indicates that the given
+// namespace does not exist
+func isMissingNamespace(err error, namespace string) bool {
+	if namespace == "" || !apierrors.IsNotFound(err) {
+		return false
+	}
+	status, ok := err.(apierrors.APIStatus)
+	if !ok {
+		return false
+	}
+	details := status.Status().Details
+	return details != nil && details.Kind == "namespaces" && details.Name == namespace
+}
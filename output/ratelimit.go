@@ -0,0 +1,119 @@
+package output
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// waitForAccess blocks until the configured rate limit permits an API call,
+// backing off for the duration returned by the rate limit
+func (k *Kubernetes) waitForAccess(ctx context.Context) error {
+	if k.rateLimit == nil {
+		return nil
+	}
+	for {
+		period, err := k.rateLimit.Access()
+		if err != nil {
+			k.log.Errorf("rate limit error: %v", err)
+			k.mLimitErr.Incr(1)
+			period = time.Second
+		}
+		if period <= 0 {
+			return nil
+		}
+		if err == nil {
+			k.mLimited.Incr(1)
+			k.mLimitFor.Incr(period.Nanoseconds() / int64(time.Millisecond))
+		}
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// rateLimitedClient is a client that waits for access to a rate limit prior
+// to every API call, such that retries and high throughput writes respect a
+// shared budget
+type rateLimitedClient struct {
+	client.Client
+	wait func(context.Context) error
+}
+
+func (c *rateLimitedClient) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *rateLimitedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *rateLimitedClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *rateLimitedClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *rateLimitedClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *rateLimitedClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *rateLimitedClient) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *rateLimitedClient) Status() client.StatusWriter {
+	return &rateLimitedStatusWriter{StatusWriter: c.Client.Status(), wait: c.wait}
+}
+
+type rateLimitedStatusWriter struct {
+	client.StatusWriter
+	wait func(context.Context) error
+}
+
+func (w *rateLimitedStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.wait(ctx); err != nil {
+		return err
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *rateLimitedStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.wait(ctx); err != nil {
+		return err
+	}
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+//------------------------------------------------------------------------------
+
+// TargetConfig identifies the object written by the output independently of
+// the message body, where each field supports interpolation functions
+type TargetConfig struct {
+	Group     string `json:"group" yaml:"group"`
+	Kind      string `json:"kind" yaml:"kind"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Version   string `json:"version" yaml:"version"`
+}
+
+// NewTargetConfig returns a TargetConfig with default values
+func NewTargetConfig() TargetConfig {
+	return TargetConfig{}
+}
+
+// targetFields contains the parsed interpolation fields of a target
+type targetFields struct {
+	group     bloblang.Field
+	kind      bloblang.Field
+	name      bloblang.Field
+	namespace bloblang.Field
+	version   bloblang.Field
+}
+
+// newTargetFields parses the fields of a target, returning nil if no fields
+// are specified
+func newTargetFields(conf TargetConfig) (*targetFields, error) {
+	if conf == (TargetConfig{}) {
+		return nil, nil
+	}
+	var t targetFields
+	var err error
+	if t.group, err = bloblang.NewField(conf.Group); err != nil {
+		return nil, fmt.Errorf("error parsing target group: %v", err)
+	}
+	if t.kind, err = bloblang.NewField(conf.Kind); err != nil {
+		return nil, fmt.Errorf("error parsing target kind: %v", err)
+	}
+	if t.name, err = bloblang.NewField(conf.Name); err != nil {
+		return nil, fmt.Errorf("error parsing target name: %v", err)
+	}
+	if t.namespace, err = bloblang.NewField(conf.Namespace); err != nil {
+		return nil, fmt.Errorf("error parsing target namespace: %v", err)
+	}
+	if t.version, err = bloblang.NewField(conf.Version); err != nil {
+		return nil, fmt.Errorf("error parsing target version: %v", err)
+	}
+	return &t, nil
+}
+
+// apply sets the identity of the given object from the target fields, which
+// take precedence over the object, leaving the object unchanged for any empty
+// fields. The group and version are overridden together, such that an empty
+// group resolves to the core group when a version is specified.
+func (t *targetFields) apply(index int, msg types.Message, u *unstructured.Unstructured) error {
+	if t == nil {
+		return nil
+	}
+	gvk := u.GroupVersionKind()
+	group, version := t.group.String(index, msg), t.version.String(index, msg)
+	if group != "" || version != "" {
+		gvk.Group, gvk.Version = group, version
+	}
+	if kind := t.kind.String(index, msg); kind != "" {
+		gvk.Kind = kind
+	}
+	if gvk.Version == "" || gvk.Kind == "" {
+		return fmt.Errorf("object version and kind are required, got %s", gvk.String())
+	}
+	u.SetGroupVersionKind(gvk)
+
+	if namespace := t.namespace.String(index, msg); namespace != "" {
+		u.SetNamespace(namespace)
+	}
+	if name := t.name.String(index, msg); name != "" {
+		u.SetName(name)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package processor
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// get fetches an object, reading from the informer cache of a kubernetes input
+// sharing the same client if enabled and available, and from the API server
+// otherwise
+func (k *Kubernetes) get(ctx context.Context, key client.ObjectKey, u *unstructured.Unstructured) error {
+	if k.useCache {
+		gvk := u.GroupVersionKind()
+		if reader, typed, ok := k.resource.Cache(gvk); ok {
+			err := k.getCached(ctx, reader, typed, key, u)
+			if err == nil {
+				return nil
+			}
+			// objects excluded from the cache (e.g. by a selector pushed down
+			// to the cache) are read from the API server instead
+			k.log.Debugf("falling back to api server for %s %s: %v", gvk.String(), key.String(), err)
+		}
+	}
+	c, err := k.resource.Client()
+	if err != nil {
+		return err
+	}
+	return c.Get(ctx, key, u)
+}
+
+// getCached reads an object from an informer cache, converting typed objects
+// to unstructured objects
+func (k *Kubernetes) getCached(ctx context.Context, reader client.Reader, typed bool, key client.ObjectKey, u *unstructured.Unstructured) error {
+	gvk := u.GroupVersionKind()
+	if !typed {
+		return reader.Get(ctx, key, u)
+	}
+
+	scheme, err := k.resource.Scheme()
+	if err != nil {
+		return err
+	}
+	obj, err := scheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	if err := reader.Get(ctx, key, obj); err != nil {
+		return err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	u.Object = content
+	u.SetGroupVersionKind(gvk)
+	return nil
+}
@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// ConditionConfig defines runtime configuration for the set_condition
+// operator, where each field supports interpolation functions
+type ConditionConfig struct {
+	Message string `json:"message" yaml:"message"`
+	Reason  string `json:"reason" yaml:"reason"`
+	Status  string `json:"status" yaml:"status"`
+	Type    string `json:"type" yaml:"type"`
+}
+
+// NewConditionConfig returns a ConditionConfig with default values
+func NewConditionConfig() ConditionConfig {
+	return ConditionConfig{}
+}
+
+// conditionFields contains the parsed interpolation fields of a condition
+type conditionFields struct {
+	message bloblang.Field
+	reason  bloblang.Field
+	status  bloblang.Field
+	typ     bloblang.Field
+}
+
+func newConditionFields(conf ConditionConfig) (*conditionFields, error) {
+	var c conditionFields
+	var err error
+	if c.message, err = bloblang.NewField(conf.Message); err != nil {
+		return nil, fmt.Errorf("error parsing message: %v", err)
+	}
+	if c.reason, err = bloblang.NewField(conf.Reason); err != nil {
+		return nil, fmt.Errorf("error parsing reason: %v", err)
+	}
+	if c.status, err = bloblang.NewField(conf.Status); err != nil {
+		return nil, fmt.Errorf("error parsing status: %v", err)
+	}
+	if c.typ, err = bloblang.NewField(conf.Type); err != nil {
+		return nil, fmt.Errorf("error parsing type: %v", err)
+	}
+	return &c, nil
+}
+
+// condition mirrors the fields of a standard status condition
+type condition struct {
+	Message string
+	Reason  string
+	Status  string
+	Type    string
+}
+
+// resolve evaluates the condition fields for a message part
+func (c *conditionFields) resolve(index int, msg types.Message) (condition, error) {
+	cond := condition{
+		Message: c.message.String(index, msg),
+		Reason:  c.reason.String(index, msg),
+		Status:  c.status.String(index, msg),
+		Type:    c.typ.String(index, msg),
+	}
+	if cond.Type == "" {
+		return cond, errors.New("condition type is required")
+	}
+	switch cond.Status {
+	case "True", "False", "Unknown":
+	default:
+		return cond, fmt.Errorf("invalid condition status: %q, must be one of True, False, Unknown", cond.Status)
+	}
+	if cond.Reason == "" {
+		return cond, errors.New("condition reason is required")
+	}
+	return cond, nil
+}
+
+//------------------------------------------------------------------------------
+
+// setCondition upserts a condition into the status.conditions of the live
+// object and updates its status subresource
+func (k *Kubernetes) setCondition(ctx context.Context, u *unstructured.Unstructured, cond condition) error {
+	key, err := client.ObjectKeyFromObject(u)
+	if err != nil {
+		return fmt.Errorf("failed to get object key from object: %v", err)
+	}
+	c, err := k.resource.Client()
+	if err != nil {
+		return err
+	}
+	if err := c.Get(ctx, key, u); err != nil {
+		return fmt.Errorf("error getting object: %v", err)
+	}
+
+	conditions, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil {
+		return fmt.Errorf("error reading status.conditions: %v", err)
+	}
+	conditions = upsertCondition(conditions, cond, u.GetGeneration(), time.Now())
+	if err := unstructured.SetNestedSlice(u.Object, conditions, "status", "conditions"); err != nil {
+		return fmt.Errorf("error setting status.conditions: %v", err)
+	}
+
+	return c.Status().Update(ctx, u)
+}
+
+// upsertCondition sets a condition within a list of conditions following the
+// semantics of metav1.Condition, where lastTransitionTime is only updated
+// when the status of the condition changes
+func upsertCondition(conditions []interface{}, cond condition, generation int64, now time.Time) []interface{} {
+	for i, item := range conditions {
+		existing, ok := item.(map[string]interface{})
+		if !ok || existing["type"] != cond.Type {
+			continue
+		}
+		if existing["status"] != cond.Status {
+			existing["lastTransitionTime"] = now.UTC().Format(time.RFC3339)
+		} else if _, ok := existing["lastTransitionTime"]; !ok {
+			existing["lastTransitionTime"] = now.UTC().Format(time.RFC3339)
+		}
+		existing["status"] = cond.Status
+		existing["reason"] = cond.Reason
+		existing["message"] = cond.Message
+		existing["observedGeneration"] = generation
+		conditions[i] = existing
+		return conditions
+	}
+
+	return append(conditions, map[string]interface{}{
+		"type":               cond.Type,
+		"status":             cond.Status,
+		"reason":             cond.Reason,
+		"message":            cond.Message,
+		"observedGeneration": generation,
+		"lastTransitionTime": now.UTC().Format(time.RFC3339),
+	})
+}
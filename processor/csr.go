@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+)
+
+//------------------------------------------------------------------------------
+
+// CSRConfig defines runtime configuration for the csr_approve and csr_deny
+// operators, where each field supports interpolation functions
+type CSRConfig struct {
+	Message string `json:"message" yaml:"message"`
+	Reason  string `json:"reason" yaml:"reason"`
+}
+
+// NewCSRConfig returns a CSRConfig with default values
+func NewCSRConfig() CSRConfig {
+	return CSRConfig{}
+}
+
+// csrFields contains the parsed interpolation fields of a CSR decision
+type csrFields struct {
+	message bloblang.Field
+	reason  bloblang.Field
+}
+
+func newCSRFields(conf CSRConfig) (*csrFields, error) {
+	var c csrFields
+	var err error
+	if c.message, err = bloblang.NewField(conf.Message); err != nil {
+		return nil, fmt.Errorf("error parsing message: %v", err)
+	}
+	if c.reason, err = bloblang.NewField(conf.Reason); err != nil {
+		return nil, fmt.Errorf("error parsing reason: %v", err)
+	}
+	return &c, nil
+}
+
+// csrGroupKind identifies certificate signing requests in all API versions
+var csrGroupKind = schema.GroupKind{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}
+
+//------------------------------------------------------------------------------
+
+// decideCSR approves or denies the named certificate signing request by
+// adding the corresponding condition via the approval subresource, using the
+// preferred API version served by the cluster
+func (k *Kubernetes) decideCSR(ctx context.Context, name string, approve bool, index int, msg types.Message) (*unstructured.Unstructured, error) {
+	conditionType, opposite, reason := "Approved", "Denied", "BenthosApprove"
+	if !approve {
+		conditionType, opposite, reason = "Denied", "Approved", "BenthosDeny"
+	}
+	if r := k.csr.reason.String(index, msg); r != "" {
+		reason = r
+	}
+	message := k.csr.message.String(index, msg)
+
+	mapper, err := k.resource.Mapper()
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := mapper.RESTMapping(csrGroupKind)
+	if err != nil {
+		return nil, fmt.Errorf("error mapping resource: %v", err)
+	}
+	dynamicClient, err := k.resource.Dynamic()
+	if err != nil {
+		return nil, err
+	}
+	rc := dynamicClient.Resource(mapping.Resource)
+
+	var result *unstructured.Unstructured
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		csr, err := rc.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		conditions, _, err := unstructured.NestedSlice(csr.Object, "status", "conditions")
+		if err != nil {
+			return fmt.Errorf("error reading conditions: %v", err)
+		}
+		for _, c := range conditions {
+			cond, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch cond["type"] {
+			case conditionType:
+				// the decision has already been made
+				result = csr
+				return nil
+			case opposite:
+				return fmt.Errorf("certificate signing request has already been %s", opposite)
+			}
+		}
+
+		cond := map[string]interface{}{
+			"type":           conditionType,
+			"reason":         reason,
+			"message":        message,
+			"lastUpdateTime": time.Now().UTC().Format(time.RFC3339),
+		}
+		// the condition status is required by v1, and is not supported by
+		// v1beta1 prior to kubernetes 1.19
+		if mapping.GroupVersionKind.Version != "v1beta1" {
+			cond["status"] = "True"
+		}
+		conditions = append(conditions, cond)
+		if err := unstructured.SetNestedSlice(csr.Object, conditions, "status", "conditions"); err != nil {
+			return err
+		}
+
+		result, err = rc.Update(ctx, csr, metav1.UpdateOptions{}, "approval")
+		return err
+	})
+	if err != nil {
+		if apierrors.IsForbidden(err) {
+			return nil, fmt.Errorf("permission denied, requires update permission on the certificatesigningrequests/approval subresource and approve permission on the signer of the request: %v", err)
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,183 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Supported diff formats
+const (
+	DiffFormatFields     = "fields"
+	DiffFormatMergePatch = "merge_patch"
+)
+
+//------------------------------------------------------------------------------
+
+// DiffConfig defines runtime configuration for the diff operator
+type DiffConfig struct {
+	Current            string `json:"current" yaml:"current"`
+	Desired            string `json:"desired" yaml:"desired"`
+	Format             string `json:"format" yaml:"format"`
+	IgnoreServerFields bool   `json:"ignore_server_fields" yaml:"ignore_server_fields"`
+}
+
+// NewDiffConfig returns a DiffConfig with default values
+func NewDiffConfig() DiffConfig {
+	return DiffConfig{
+		Current: "current",
+		Desired: "desired",
+		Format:  DiffFormatMergePatch,
+	}
+}
+
+// serverFields lists the paths of fields populated by the API server that
+// are removed prior to diffing when ignore_server_fields is enabled
+var serverFields = [][]string{
+	{"metadata", "creationTimestamp"},
+	{"metadata", "generation"},
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "selfLink"},
+	{"metadata", "uid"},
+	{"status"},
+}
+
+// fieldDiff describes a single changed field
+type fieldDiff struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+// diff computes the delta between the current and desired documents contained
+// in a message body, returning the encoded delta and whether any fields differ
+func (k *Kubernetes) diff(b []byte) ([]byte, bool, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, false, fmt.Errorf("invalid message part, must be a JSON object: %v", err)
+	}
+
+	current, err := diffDocument(doc, k.diffConf.Current)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading current: %v", err)
+	}
+	desired, err := diffDocument(doc, k.diffConf.Desired)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading desired: %v", err)
+	}
+	if k.diffConf.IgnoreServerFields {
+		for _, path := range serverFields {
+			unstructured.RemoveNestedField(current, path...)
+			unstructured.RemoveNestedField(desired, path...)
+		}
+	}
+
+	var delta interface{}
+	var changed bool
+	switch k.diffConf.Format {
+	case DiffFormatFields:
+		diffs := diffFields(nil, current, desired, []fieldDiff{})
+		delta, changed = diffs, len(diffs) > 0
+	default:
+		patch := mergePatch(current, desired)
+		delta, changed = patch, len(patch) > 0
+	}
+
+	res, err := json.Marshal(delta)
+	if err != nil {
+		return nil, false, fmt.Errorf("error marshalling diff: %v", err)
+	}
+	return res, changed, nil
+}
+
+// diffDocument returns the object at the given dot separated path, treating a
+// missing or null value as an empty object
+func diffDocument(doc map[string]interface{}, path string) (map[string]interface{}, error) {
+	v, found, err := unstructured.NestedFieldNoCopy(doc, strings.Split(path, ".")...)
+	if err != nil {
+		return nil, err
+	}
+	if !found || v == nil {
+		return map[string]interface{}{}, nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object", path)
+	}
+	return obj, nil
+}
+
+// mergePatch returns the JSON merge patch (RFC 7386) that transforms current
+// into desired, where removed fields are set to null and arrays are replaced
+// as a whole
+func mergePatch(current, desired map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key, from := range current {
+		if _, ok := desired[key]; !ok {
+			patch[key] = nil
+			continue
+		}
+		to := desired[key]
+		fromMap, fromOK := from.(map[string]interface{})
+		toMap, toOK := to.(map[string]interface{})
+		if fromOK && toOK {
+			if nested := mergePatch(fromMap, toMap); len(nested) > 0 {
+				patch[key] = nested
+			}
+			continue
+		}
+		if !reflect.DeepEqual(from, to) {
+			patch[key] = to
+		}
+	}
+	for key, to := range desired {
+		if _, ok := current[key]; !ok {
+			patch[key] = to
+		}
+	}
+	return patch
+}
+
+// diffFields appends a fieldDiff for each added, removed, or replaced field,
+// sorted by path, where arrays are compared as a whole
+func diffFields(path []string, current, desired map[string]interface{}, diffs []fieldDiff) []fieldDiff {
+	keys := make([]string, 0, len(current)+len(desired))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range desired {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fieldPath := append(append([]string{}, path...), key)
+		from, inCurrent := current[key]
+		to, inDesired := desired[key]
+		switch {
+		case !inCurrent:
+			diffs = append(diffs, fieldDiff{Path: strings.Join(fieldPath, "."), Op: "add", To: to})
+		case !inDesired:
+			diffs = append(diffs, fieldDiff{Path: strings.Join(fieldPath, "."), Op: "remove", From: from})
+		default:
+			fromMap, fromOK := from.(map[string]interface{})
+			toMap, toOK := to.(map[string]interface{})
+			if fromOK && toOK {
+				diffs = diffFields(fieldPath, fromMap, toMap, diffs)
+			} else if !reflect.DeepEqual(from, to) {
+				diffs = append(diffs, fieldDiff{Path: strings.Join(fieldPath, "."), Op: "replace", From: from, To: to})
+			}
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+//------------------------------------------------------------------------------
+
+// DrainConfig defines runtime configuration for the drain operator
+type DrainConfig struct {
+	DeleteEmptydirData bool   `json:"delete_emptydir_data" yaml:"delete_emptydir_data"`
+	Evict              bool   `json:"evict" yaml:"evict"`
+	Force              bool   `json:"force" yaml:"force"`
+	GracePeriod        int64  `json:"grace_period" yaml:"grace_period"`
+	IgnoreDaemonsets   bool   `json:"ignore_daemonsets" yaml:"ignore_daemonsets"`
+	Timeout            string `json:"timeout" yaml:"timeout"`
+}
+
+// NewDrainConfig returns a DrainConfig with default values
+func NewDrainConfig() DrainConfig {
+	return DrainConfig{
+		Evict:       true,
+		GracePeriod: -1,
+		Timeout:     "5m",
+	}
+}
+
+// evictionRetryInterval is the period to wait before retrying an eviction
+// that was rejected due to a pod disruption budget
+const evictionRetryInterval = 5 * time.Second
+
+//------------------------------------------------------------------------------
+
+// drain cordons the given node and optionally evicts its pods, returning the
+// names of the evicted pods
+func (k *Kubernetes) drain(ctx context.Context, node string) ([]string, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	// cordon node
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+	if _, err := clientset.CoreV1().Nodes().Patch(ctx, node, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return nil, fmt.Errorf("error cordoning node: %v", err)
+	}
+	if !k.drainConf.Evict {
+		return nil, nil
+	}
+
+	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": node}).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+
+	// determine the set of pods to evict, failing if any pods cannot be
+	// evicted under the current options
+	var targets []corev1.Pod
+	for _, pod := range pods.Items {
+		evict, err := k.drainFilter(pod)
+		if err != nil {
+			return nil, err
+		}
+		if evict {
+			targets = append(targets, pod)
+		}
+	}
+
+	if k.drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, k.drainTimeout)
+		defer cancel()
+	}
+
+	evicted := []string{}
+	for _, pod := range targets {
+		if err := k.evict(ctx, pod); err != nil {
+			return evicted, fmt.Errorf("error evicting pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		evicted = append(evicted, pod.Namespace+"/"+pod.Name)
+	}
+	return evicted, nil
+}
+
+// drainFilter determines whether a pod should be evicted, mirroring the
+// behavior of kubectl drain
+func (k *Kubernetes) drainFilter(pod corev1.Pod) (bool, error) {
+	// mirror pods are managed by the kubelet and cannot be evicted
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return false, nil
+	}
+	// completed pods can be evicted without further checks
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true, nil
+	}
+
+	controller := metav1.GetControllerOf(&pod)
+	if controller != nil && controller.Kind == "DaemonSet" {
+		if !k.drainConf.IgnoreDaemonsets {
+			return false, fmt.Errorf("cannot drain pod %s/%s managed by a DaemonSet without ignore_daemonsets", pod.Namespace, pod.Name)
+		}
+		return false, nil
+	}
+	if controller == nil && !k.drainConf.Force {
+		return false, fmt.Errorf("cannot drain pod %s/%s not managed by a controller without force", pod.Namespace, pod.Name)
+	}
+	for _, v := range pod.Spec.Volumes {
+		if v.EmptyDir != nil && !k.drainConf.DeleteEmptydirData {
+			return false, fmt.Errorf("cannot drain pod %s/%s with local storage without delete_emptydir_data", pod.Namespace, pod.Name)
+		}
+	}
+	return true, nil
+}
+
+// evict evicts a single pod, retrying while the eviction is rejected due to a
+// pod disruption budget
+func (k *Kubernetes) evict(ctx context.Context, pod corev1.Pod) error {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return err
+	}
+
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	if k.drainConf.GracePeriod >= 0 {
+		gracePeriod := k.drainConf.GracePeriod
+		eviction.DeleteOptions = &metav1.DeleteOptions{
+			GracePeriodSeconds: &gracePeriod,
+		}
+	}
+
+	for {
+		err := clientset.PolicyV1beta1().Evictions(pod.Namespace).Evict(ctx, eviction)
+		switch {
+		case err == nil, apierrors.IsNotFound(err):
+			return nil
+		case apierrors.IsTooManyRequests(err):
+			k.log.Debugf("eviction of pod %s/%s rejected by disruption budget, retrying", pod.Namespace, pod.Name)
+		default:
+			return err
+		}
+
+		select {
+		case <-time.After(evictionRetryInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for disruption budget: %v", ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+//------------------------------------------------------------------------------
+
+// EndpointsConfig defines runtime configuration for the endpoints operator
+type EndpointsConfig struct {
+	Metadata string `json:"metadata" yaml:"metadata"`
+	Port     string `json:"port" yaml:"port"`
+}
+
+// NewEndpointsConfig returns an EndpointsConfig with default values
+func NewEndpointsConfig() EndpointsConfig {
+	return EndpointsConfig{}
+}
+
+// endpointAddress describes a single ready address backing a service
+type endpointAddress struct {
+	IP       string         `json:"ip"`
+	Hostname string         `json:"hostname,omitempty"`
+	NodeName string         `json:"node_name,omitempty"`
+	Pod      string         `json:"pod,omitempty"`
+	Ports    []endpointPort `json:"ports"`
+}
+
+// endpointPort describes a single port exposed by an endpoint address
+type endpointPort struct {
+	Name     string `json:"name,omitempty"`
+	Port     int32  `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+// endpoints resolves the ready addresses backing the given service, using
+// EndpointSlices where available and falling back to Endpoints otherwise
+func (k *Kubernetes) endpoints(ctx context.Context, namespace, name string) ([]endpointAddress, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting service: %v", err)
+	}
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return nil, fmt.Errorf("service of type %s has no endpoints", corev1.ServiceTypeExternalName)
+	}
+
+	addrs, found, err := k.endpointSliceAddresses(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		if addrs, err = k.endpointsAddresses(ctx, namespace, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if k.endpointsConf.Port != "" {
+		addrs = filterEndpointPort(addrs, k.endpointsConf.Port)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].IP < addrs[j].IP
+	})
+	return addrs, nil
+}
+
+// endpointSliceAddresses returns the ready addresses of all EndpointSlices
+// belonging to a service, and false if none exist or the API is not served
+func (k *Kubernetes) endpointSliceAddresses(ctx context.Context, namespace, name string) ([]endpointAddress, bool, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return nil, false, err
+	}
+
+	slices, err := clientset.DiscoveryV1beta1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{discoveryv1beta1.LabelServiceName: name}).String(),
+	})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("error listing endpoint slices: %v", err)
+	}
+	if len(slices.Items) == 0 {
+		return nil, false, nil
+	}
+
+	addrs := []endpointAddress{}
+	seen := map[string]int{}
+	for _, slice := range slices.Items {
+		ports := make([]endpointPort, 0, len(slice.Ports))
+		for _, p := range slice.Ports {
+			var port endpointPort
+			if p.Name != nil {
+				port.Name = *p.Name
+			}
+			if p.Port != nil {
+				port.Port = *p.Port
+			}
+			if p.Protocol != nil {
+				port.Protocol = string(*p.Protocol)
+			}
+			ports = append(ports, port)
+		}
+
+		for _, ep := range slice.Endpoints {
+			// endpoints with an unknown ready condition are considered ready
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			for _, ip := range ep.Addresses {
+				// an address may appear in multiple slices, either briefly
+				// during a move or with differing port sets
+				if i, ok := seen[ip]; ok {
+					addrs[i].Ports = mergeEndpointPorts(addrs[i].Ports, ports)
+					continue
+				}
+				seen[ip] = len(addrs)
+
+				addr := endpointAddress{
+					IP:    ip,
+					Ports: ports,
+				}
+				if ep.Hostname != nil {
+					addr.Hostname = *ep.Hostname
+				}
+				if nodeName, ok := ep.Topology[corev1.LabelHostname]; ok {
+					addr.NodeName = nodeName
+				}
+				if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+					addr.Pod = ep.TargetRef.Name
+				}
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs, true, nil
+}
+
+// endpointsAddresses returns the ready addresses of the Endpoints object of a
+// service
+func (k *Kubernetes) endpointsAddresses(ctx context.Context, namespace, name string) ([]endpointAddress, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	eps, err := clientset.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return []endpointAddress{}, nil
+		}
+		return nil, fmt.Errorf("error getting endpoints: %v", err)
+	}
+
+	addrs := []endpointAddress{}
+	for _, subset := range eps.Subsets {
+		ports := make([]endpointPort, 0, len(subset.Ports))
+		for _, p := range subset.Ports {
+			ports = append(ports, endpointPort{
+				Name:     p.Name,
+				Port:     p.Port,
+				Protocol: string(p.Protocol),
+			})
+		}
+
+		for _, a := range subset.Addresses {
+			addr := endpointAddress{
+				IP:       a.IP,
+				Hostname: a.Hostname,
+				Ports:    ports,
+			}
+			if a.NodeName != nil {
+				addr.NodeName = *a.NodeName
+			}
+			if a.TargetRef != nil && a.TargetRef.Kind == "Pod" {
+				addr.Pod = a.TargetRef.Name
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs, nil
+}
+
+// mergeEndpointPorts returns the union of two port lists
+func mergeEndpointPorts(ports, other []endpointPort) []endpointPort {
+	merged := append([]endpointPort{}, ports...)
+	for _, p := range other {
+		exists := false
+		for _, existing := range merged {
+			if existing == p {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// filterEndpointPort restricts the ports of each address to those matching
+// the given port name or number, dropping addresses without a matching port
+func filterEndpointPort(addrs []endpointAddress, port string) []endpointAddress {
+	filtered := []endpointAddress{}
+	for _, addr := range addrs {
+		var ports []endpointPort
+		for _, p := range addr.Ports {
+			if p.Name == port || strconv.Itoa(int(p.Port)) == port {
+				ports = append(ports, p)
+			}
+		}
+		if len(ports) == 0 {
+			continue
+		}
+		addr.Ports = ports
+		filtered = append(filtered, addr)
+	}
+	return filtered
+}
@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+//------------------------------------------------------------------------------
+
+// EventsConfig defines runtime configuration for the events operator
+type EventsConfig struct {
+	Field string   `json:"field" yaml:"field"`
+	Limit int      `json:"limit" yaml:"limit"`
+	Order string   `json:"order" yaml:"order"`
+	Types []string `json:"types" yaml:"types"`
+}
+
+// NewEventsConfig returns an EventsConfig with default values
+func NewEventsConfig() EventsConfig {
+	return EventsConfig{
+		Order: EventsOrderDesc,
+		Types: []string{},
+	}
+}
+
+// Supported event orders
+const (
+	EventsOrderAsc  = "asc"
+	EventsOrderDesc = "desc"
+)
+
+// validate verifies the events configuration
+func (c EventsConfig) validate() error {
+	if c.Limit < 0 {
+		return fmt.Errorf("invalid events limit: %d", c.Limit)
+	}
+	switch c.Order {
+	case EventsOrderAsc, EventsOrderDesc:
+	default:
+		return fmt.Errorf("invalid events order: %s", c.Order)
+	}
+	for _, t := range c.Types {
+		switch t {
+		case corev1.EventTypeNormal, corev1.EventTypeWarning:
+		default:
+			return fmt.Errorf("invalid events type: %s", t)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// events lists the events involving the given object, filtered by the
+// configured types, keeping the most recent events up to the configured limit
+// in the configured order. Events are matched by the kind, name, and uid (if
+// known) of the object, such that events of a previous object with the same
+// name are excluded.
+func (k *Kubernetes) events(ctx context.Context, u *unstructured.Unstructured) ([]corev1.Event, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	selector := fields.Set{
+		"involvedObject.kind": u.GetKind(),
+		"involvedObject.name": u.GetName(),
+	}
+	if uid := u.GetUID(); uid != "" {
+		selector["involvedObject.uid"] = string(uid)
+	}
+	// events of cluster scoped objects are recorded in the default namespace,
+	// such that they are listed across all namespaces
+	if ns := u.GetNamespace(); ns != "" {
+		selector["involvedObject.namespace"] = ns
+	}
+
+	list, err := clientset.CoreV1().Events(u.GetNamespace()).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing events: %v", err)
+	}
+
+	types := map[string]bool{}
+	for _, t := range k.eventsConf.Types {
+		types[t] = true
+	}
+	events := []corev1.Event{}
+	for _, e := range list.Items {
+		if len(types) > 0 && !types[e.Type] {
+			continue
+		}
+		e.APIVersion, e.Kind = "v1", "Event"
+		events = append(events, e)
+	}
+
+	// keep the most recent events, sorting newest first
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(&events[i]).After(eventTime(&events[j]))
+	})
+	if k.eventsConf.Limit > 0 && len(events) > k.eventsConf.Limit {
+		events = events[:k.eventsConf.Limit]
+	}
+	if k.eventsConf.Order == EventsOrderAsc {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	return events, nil
+}
+
+// eventTime returns the time an event was last observed, which is recorded in
+// lastTimestamp by the core/v1 api, and in eventTime or series by reporters
+// using the events.k8s.io api
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.FirstTimestamp.IsZero():
+		return e.FirstTimestamp.Time
+	}
+	return e.CreationTimestamp.Time
+}
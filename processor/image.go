@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+//------------------------------------------------------------------------------
+
+// ImageDigestsConfig defines runtime configuration for the image_digests
+// operator
+type ImageDigestsConfig struct {
+	Metadata string `json:"metadata" yaml:"metadata"`
+}
+
+// NewImageDigestsConfig returns an ImageDigestsConfig with default values
+func NewImageDigestsConfig() ImageDigestsConfig {
+	return ImageDigestsConfig{}
+}
+
+// Supported container types of an image digest
+const (
+	containerTypeContainer = "container"
+	containerTypeEphemeral = "ephemeral"
+	containerTypeInit      = "init"
+)
+
+// imageDigest describes the image resolved for a single container of a pod
+type imageDigest struct {
+	Container string `json:"container"`
+	Type      string `json:"type"`
+	Image     string `json:"image"`
+	ImageID   string `json:"image_id"`
+	Digest    string `json:"digest"`
+}
+
+//------------------------------------------------------------------------------
+
+// imageDigests returns the images resolved by the kubelet for the init,
+// regular, and ephemeral containers of a pod, in that order, correlating the
+// image ids reported by the container statuses of the pod with the images of
+// its spec. Containers without a status (e.g. of pods that are not yet
+// scheduled) are omitted.
+func imageDigests(u *unstructured.Unstructured) ([]imageDigest, error) {
+	var pod corev1.Pod
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &pod); err != nil {
+		return nil, fmt.Errorf("error converting pod: %v", err)
+	}
+
+	images := map[string]string{}
+	for _, c := range pod.Spec.InitContainers {
+		images[c.Name] = c.Image
+	}
+	for _, c := range pod.Spec.Containers {
+		images[c.Name] = c.Image
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		images[c.Name] = c.Image
+	}
+
+	digests := []imageDigest{}
+	add := func(containerType string, statuses []corev1.ContainerStatus) {
+		for _, s := range statuses {
+			image, ok := images[s.Name]
+			if !ok || image == "" {
+				image = s.Image
+			}
+			digests = append(digests, imageDigest{
+				Container: s.Name,
+				Type:      containerType,
+				Image:     image,
+				ImageID:   s.ImageID,
+				Digest:    imageIDDigest(s.ImageID),
+			})
+		}
+	}
+	add(containerTypeInit, pod.Status.InitContainerStatuses)
+	add(containerTypeContainer, pod.Status.ContainerStatuses)
+	add(containerTypeEphemeral, pod.Status.EphemeralContainerStatuses)
+	return digests, nil
+}
+
+// imageIDDigest extracts the repository digest from an image id reported by a
+// container runtime (e.g. docker-pullable://nginx@sha256:...), returning an
+// empty string if the image id does not reference a repository digest (e.g.
+// images that were built locally rather than pulled)
+func imageIDDigest(imageID string) string {
+	i := strings.LastIndex(imageID, "@")
+	if i < 0 {
+		return ""
+	}
+	return imageID[i+1:]
+}
@@ -0,0 +1,177 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Job results reported via the job_status metadata field
+const (
+	jobStatusFailed    = "failed"
+	jobStatusSucceeded = "succeeded"
+	jobStatusTimeout   = "timeout"
+)
+
+//------------------------------------------------------------------------------
+
+// RunJobConfig defines runtime configuration for the run_job operator
+type RunJobConfig struct {
+	Cleanup        bool   `json:"cleanup" yaml:"cleanup"`
+	Logs           bool   `json:"logs" yaml:"logs"`
+	LogsLimitBytes int64  `json:"logs_limit_bytes" yaml:"logs_limit_bytes"`
+	PollInterval   string `json:"poll_interval" yaml:"poll_interval"`
+	Timeout        string `json:"timeout" yaml:"timeout"`
+}
+
+// NewRunJobConfig returns a RunJobConfig with default values
+func NewRunJobConfig() RunJobConfig {
+	return RunJobConfig{
+		LogsLimitBytes: 1 << 20,
+		PollInterval:   "5s",
+		Timeout:        "10m",
+	}
+}
+
+// jobResult describes the outcome of a job run by the run_job operator
+type jobResult struct {
+	job    *batchv1.Job
+	status string
+	logs   string
+}
+
+//------------------------------------------------------------------------------
+
+// runJob creates a job from the given object and waits for it to complete or
+// fail, optionally fetching the logs of its most recent pod
+func (k *Kubernetes) runJob(ctx context.Context, u *unstructured.Unstructured) (*jobResult, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	var job batchv1.Job
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &job); err != nil {
+		return nil, fmt.Errorf("error converting job: %v", err)
+	}
+
+	jobs := clientset.BatchV1().Jobs(job.Namespace)
+	created, err := jobs.Create(ctx, &job, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating job: %v", err)
+	}
+	res := &jobResult{job: created}
+
+	waitCtx := ctx
+	if k.runJobTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, k.runJobTimeout)
+		defer cancel()
+	}
+
+	for res.status == "" {
+		select {
+		case <-time.After(k.runJobPollInterval):
+		case <-waitCtx.Done():
+			res.status = jobStatusTimeout
+			continue
+		}
+
+		current, err := jobs.Get(waitCtx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			if waitCtx.Err() != nil {
+				res.status = jobStatusTimeout
+				continue
+			}
+			return res, fmt.Errorf("error getting job: %v", err)
+		}
+		res.job = current
+		res.status = jobStatus(current)
+	}
+
+	if k.runJobConf.Logs {
+		logs, err := k.jobLogs(ctx, res.job)
+		if err != nil {
+			k.log.Warnf("error fetching logs of job %s/%s: %v", res.job.Namespace, res.job.Name, err)
+		}
+		res.logs = logs
+	}
+
+	if res.status == jobStatusTimeout && k.runJobConf.Cleanup {
+		policy := metav1.DeletePropagationBackground
+		if err := jobs.Delete(ctx, res.job.Name, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+			k.log.Warnf("error deleting job %s/%s: %v", res.job.Namespace, res.job.Name, err)
+		}
+	}
+	return res, nil
+}
+
+// jobStatus returns the terminal status of a job, or an empty string if the
+// job has not yet completed or failed
+func jobStatus(job *batchv1.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return jobStatusSucceeded
+		case batchv1.JobFailed:
+			return jobStatusFailed
+		}
+	}
+	return ""
+}
+
+// jobLogs returns the logs of the most recently created pod of a job
+func (k *Kubernetes) jobLogs(ctx context.Context, job *batchv1.Job) (string, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return "", err
+	}
+
+	if job.Spec.Selector == nil {
+		return "", errors.New("job has no selector")
+	}
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+	pods, err := clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error listing pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return "", errors.New("job has no pods")
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[j].CreationTimestamp.Before(&pods.Items[i].CreationTimestamp)
+	})
+
+	// logs are fetched from the first container, given that job pods
+	// typically run a single container
+	pod := pods.Items[0]
+	opts := &corev1.PodLogOptions{}
+	if len(pod.Spec.Containers) > 0 {
+		opts.Container = pod.Spec.Containers[0].Name
+	}
+	if k.runJobConf.LogsLimitBytes > 0 {
+		limit := k.runJobConf.LogsLimitBytes
+		opts.LimitBytes = &limit
+	}
+	b, err := clientset.CoreV1().Pods(job.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
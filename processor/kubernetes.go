@@ -2,20 +2,29 @@ package processor
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Jeffail/benthos/v3/lib/bloblang"
 	"github.com/Jeffail/benthos/v3/lib/log"
+	"github.com/Jeffail/benthos/v3/lib/message"
 	"github.com/Jeffail/benthos/v3/lib/metrics"
 	"github.com/Jeffail/benthos/v3/lib/processor"
 	"github.com/Jeffail/benthos/v3/lib/types"
+	kclient "github.com/cludden/benthos-kubernetes/client"
+	"github.com/cludden/benthos-kubernetes/render"
 	"github.com/opentracing/opentracing-go"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
 
 //------------------------------------------------------------------------------
@@ -36,7 +45,7 @@ func init() {
 			if !ok {
 				return nil, errors.New("failed to cast config")
 			}
-			return NewKubernetes(*conf, logger, stats)
+			return NewKubernetes(*conf, mgr, logger, stats)
 		},
 	)
 	processor.DocumentPlugin(
@@ -50,17 +59,60 @@ func init() {
 
 // KubernetesConfig defines runtime configuration for a Kubernetes processor
 type KubernetesConfig struct {
+	kclient.Config      `json:",inline" yaml:",inline"`
+	AllowMissing        bool                       `json:"allow_missing" yaml:"allow_missing"`
+	Annotations         map[string]*string         `json:"annotations" yaml:"annotations"`
+	Client              string                     `json:"client" yaml:"client"`
+	Condition           ConditionConfig            `json:"condition" yaml:"condition"`
+	CSR                 CSRConfig                  `json:"csr" yaml:"csr"`
 	DeletionPropagation metav1.DeletionPropagation `json:"deletion_propagation" yaml:"deletion_propagation"`
+	Diff                DiffConfig                 `json:"diff" yaml:"diff"`
+	Drain               DrainConfig                `json:"drain" yaml:"drain"`
+	Endpoints           EndpointsConfig            `json:"endpoints" yaml:"endpoints"`
+	Events              EventsConfig               `json:"events" yaml:"events"`
+	Extract             string                     `json:"extract" yaml:"extract"`
+	ImageDigests        ImageDigestsConfig         `json:"image_digests" yaml:"image_digests"`
+	Labels              map[string]*string         `json:"labels" yaml:"labels"`
+	Node                NodeConfig                 `json:"node" yaml:"node"`
 	Operator            string                     `json:"operator" yaml:"operator"`
 	OperatorMapping     string                     `json:"operator_mapping" yaml:"operator_mapping"`
+	Owner               OwnerConfig                `json:"owner" yaml:"owner"`
 	Parts               []int                      `json:"parts" yaml:"parts"`
+	Render              render.Config              `json:"render" yaml:"render"`
+	Replicas            string                     `json:"replicas" yaml:"replicas"`
+	RunJob              RunJobConfig               `json:"run_job" yaml:"run_job"`
+	Taint               TaintConfig                `json:"taint" yaml:"taint"`
+	Target              TargetConfig               `json:"target" yaml:"target"`
+	TokenRequest        TokenRequestConfig         `json:"token_request" yaml:"token_request"`
+	UseCache            bool                       `json:"use_cache" yaml:"use_cache"`
+	Value               ValueConfig                `json:"value" yaml:"value"`
+	WatchOne            WatchOneConfig             `json:"watch_one" yaml:"watch_one"`
 }
 
 // NewKubernetesConfig creates a new KubernetesConfig with default values
 func NewKubernetesConfig() *KubernetesConfig {
 	return &KubernetesConfig{
+		Config:              kclient.NewConfig(),
+		Annotations:         map[string]*string{},
+		Labels:              map[string]*string{},
 		Operator:            "get",
 		DeletionPropagation: metav1.DeletePropagationBackground,
+		Condition:           NewConditionConfig(),
+		CSR:                 NewCSRConfig(),
+		Diff:                NewDiffConfig(),
+		Drain:               NewDrainConfig(),
+		Endpoints:           NewEndpointsConfig(),
+		Events:              NewEventsConfig(),
+		ImageDigests:        NewImageDigestsConfig(),
+		Node:                NewNodeConfig(),
+		Owner:               NewOwnerConfig(),
+		Render:              render.NewConfig(),
+		RunJob:              NewRunJobConfig(),
+		Taint:               NewTaintConfig(),
+		Target:              NewTargetConfig(),
+		TokenRequest:        NewTokenRequestConfig(),
+		Value:               NewValueConfig(),
+		WatchOne:            NewWatchOneConfig(),
 	}
 }
 
@@ -68,12 +120,39 @@ func NewKubernetesConfig() *KubernetesConfig {
 
 // Kubernetes is a processor that reverses all messages.
 type Kubernetes struct {
-	client client.Client
+	resource *kclient.Resource
 
+	allowMissing        bool
+	annotations         map[string]bloblang.Field
+	condition           *conditionFields
+	csr                 *csrFields
 	deletionPropagation metav1.DeletionPropagation
+	diffConf            DiffConfig
+	drainConf           DrainConfig
+	drainTimeout        time.Duration
+	endpointsConf       EndpointsConfig
+	eventsConf          EventsConfig
+	extract             bloblang.Mapping
+	imageDigestsConf    ImageDigestsConfig
+	labels              map[string]bloblang.Field
+	nodeConf            NodeConfig
 	operator            string
 	operatorMapping     bloblang.Mapping
+	ownerConf           OwnerConfig
 	parts               []int
+	render              *render.Renderer
+	replicas            bloblang.Field
+	runJobConf          RunJobConfig
+	runJobPollInterval  time.Duration
+	runJobTimeout       time.Duration
+	taint               *taintFields
+	target              *targetFields
+	tokenExpiration     time.Duration
+	tokenRequestConf    TokenRequestConfig
+	useCache            bool
+	valueRef            *valueFields
+	watchOneCheck       bloblang.Mapping
+	watchOneTimeout     time.Duration
 
 	log   log.Modular
 	stats metrics.Type
@@ -82,13 +161,25 @@ type Kubernetes struct {
 // NewKubernetes returns a Reverse processor.
 func NewKubernetes(
 	conf KubernetesConfig,
+	mgr types.Manager,
 	log log.Modular,
 	stats metrics.Type,
 ) (types.Processor, error) {
 	k := &Kubernetes{
+		allowMissing:        conf.AllowMissing,
 		deletionPropagation: conf.DeletionPropagation,
+		diffConf:            conf.Diff,
+		drainConf:           conf.Drain,
+		endpointsConf:       conf.Endpoints,
+		eventsConf:          conf.Events,
+		imageDigestsConf:    conf.ImageDigests,
+		nodeConf:            conf.Node,
 		operator:            conf.Operator,
+		ownerConf:           conf.Owner,
 		parts:               conf.Parts,
+		runJobConf:          conf.RunJob,
+		tokenRequestConf:    conf.TokenRequest,
+		useCache:            conf.UseCache,
 
 		log:   log,
 		stats: stats,
@@ -100,6 +191,21 @@ func NewKubernetes(
 		return nil, fmt.Errorf("invalid deletion propagation policy: %s", k.deletionPropagation)
 	}
 
+	switch k.diffConf.Format {
+	case DiffFormatFields, DiffFormatMergePatch:
+	default:
+		return nil, fmt.Errorf("invalid diff format: %s", k.diffConf.Format)
+	}
+	if k.diffConf.Current == "" || k.diffConf.Desired == "" {
+		return nil, errors.New("diff current and desired paths are required")
+	}
+	if k.nodeConf.Field == "" {
+		return nil, errors.New("node field is required")
+	}
+	if err := conf.Events.validate(); err != nil {
+		return nil, err
+	}
+
 	if conf.OperatorMapping != "" {
 		m, err := bloblang.NewMapping(conf.OperatorMapping)
 		if err != nil {
@@ -108,12 +214,110 @@ func NewKubernetes(
 		k.operatorMapping = m
 	}
 
-	// initalize controller manager
-	client, err := client.New(config.GetConfigOrDie(), client.Options{})
+	var err error
+	if k.annotations, err = parseNullableFields(conf.Annotations); err != nil {
+		return nil, fmt.Errorf("error parsing annotations: %v", err)
+	}
+	if k.labels, err = parseNullableFields(conf.Labels); err != nil {
+		return nil, fmt.Errorf("error parsing labels: %v", err)
+	}
+	if k.target, err = newTargetFields(conf.Target); err != nil {
+		return nil, fmt.Errorf("error parsing target: %v", err)
+	}
+	if k.valueRef, err = newValueFields(conf.Value); err != nil {
+		return nil, fmt.Errorf("error parsing value: %v", err)
+	}
+	if k.csr, err = newCSRFields(conf.CSR); err != nil {
+		return nil, fmt.Errorf("error parsing csr: %v", err)
+	}
+	if k.taint, err = newTaintFields(conf.Taint); err != nil {
+		return nil, fmt.Errorf("error parsing taint: %v", err)
+	}
+
+	cond, err := newConditionFields(conf.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing condition: %v", err)
+	}
+	k.condition = cond
+
+	if conf.Extract != "" {
+		m, err := bloblang.NewMapping(conf.Extract)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing extract mapping: %v", err)
+		}
+		k.extract = m
+	}
+
+	if conf.Drain.Timeout != "" {
+		timeout, err := time.ParseDuration(conf.Drain.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing drain timeout: %v", err)
+		}
+		k.drainTimeout = timeout
+	}
+
+	if conf.RunJob.PollInterval != "" {
+		interval, err := time.ParseDuration(conf.RunJob.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing run_job poll_interval: %v", err)
+		}
+		k.runJobPollInterval = interval
+	}
+	if k.runJobPollInterval <= 0 {
+		return nil, errors.New("run_job poll_interval must be positive")
+	}
+
+	if conf.RunJob.Timeout != "" {
+		timeout, err := time.ParseDuration(conf.RunJob.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing run_job timeout: %v", err)
+		}
+		k.runJobTimeout = timeout
+	}
+
+	if conf.WatchOne.Check != "" {
+		m, err := bloblang.NewMapping(conf.WatchOne.Check)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing watch_one check: %v", err)
+		}
+		k.watchOneCheck = m
+	}
+	if conf.WatchOne.Timeout != "" {
+		timeout, err := time.ParseDuration(conf.WatchOne.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing watch_one timeout: %v", err)
+		}
+		k.watchOneTimeout = timeout
+	}
+
+	if k.render, err = render.New(conf.Render); err != nil {
+		return nil, err
+	}
+
+	if conf.TokenRequest.Expiration != "" {
+		expiration, err := time.ParseDuration(conf.TokenRequest.Expiration)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing token_request expiration: %v", err)
+		}
+		k.tokenExpiration = expiration
+	}
+
+	if conf.Replicas != "" {
+		f, err := bloblang.NewField(conf.Replicas)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing replicas field: %v", err)
+		}
+		k.replicas = f
+	}
+
+	// clients are initialized lazily by the operations that require them,
+	// such that constructing the processor does not require the API server
+	// to be reachable
+	res, err := kclient.GetResource(mgr, conf.Client, "processor", conf.Config, log, stats)
 	if err != nil {
-		return nil, fmt.Errorf("error initializing controller manager: %v", err)
+		return nil, err
 	}
-	k.client = client
+	k.resource = res
 
 	return k, nil
 }
@@ -126,12 +330,7 @@ func (k *Kubernetes) ProcessMessage(msg types.Message) ([]types.Message, types.R
 
 	proc := func(index int, span opentracing.Span, part types.Part) error {
 		var err error
-		var u unstructured.Unstructured
-		if err := u.UnmarshalJSON(part.Get()); err != nil {
-			return fmt.Errorf("invalid message part, must be valid kubernetes runtime object: %v", err)
-		}
-		id := fmt.Sprintf("%s Namespace=%s Name=%s", u.GetObjectKind().GroupVersionKind().String(), u.GetNamespace(), u.GetName())
-
+		var result []byte
 		operator := k.operator
 		if k.operatorMapping != nil {
 			operatorB, err := k.operatorMapping.MapPart(index, msg)
@@ -141,25 +340,195 @@ func (k *Kubernetes) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			operator = string(operatorB.Get())
 		}
 
+		// the label and annotate operators identify their target using the
+		// configured target fields
+		if operator == "label" || operator == "annotate" {
+			field, values := "labels", k.labels
+			if operator == "annotate" {
+				field, values = "annotations", k.annotations
+			}
+			target, err := k.target.resolve(index, msg)
+			if err == nil {
+				k.log.Debugf("patching kubernetes object %s: %s Namespace=%s Name=%s", field, target.GroupVersionKind().String(), target.GetNamespace(), target.GetName())
+				err = k.patchMetadata(ctx, target, field, values, index, msg)
+			}
+			if err != nil {
+				err = fmt.Errorf("failed to patch %s: %v", field, err)
+				k.log.Errorf("failed to process message: %v", err)
+				return err
+			}
+			result, _, _ := unstructured.NestedStringMap(target.Object, "metadata", field)
+			if result == nil {
+				result = map[string]string{}
+			}
+			if b, jerr := json.Marshal(result); jerr == nil {
+				part.Metadata().Set(field, string(b))
+			}
+			b, err := target.MarshalJSON()
+			if err != nil {
+				return fmt.Errorf("failed to parse result object: %v", err)
+			}
+			part.Set(b)
+			return nil
+		}
+
+		// the configmap and secret operators resolve a value referenced by
+		// the configured value fields
+		if operator == "configmap" || operator == "secret" {
+			kind := "ConfigMap"
+			if operator == "secret" {
+				kind = "Secret"
+			}
+			k.log.Debugf("resolving kubernetes %s value", kind)
+			value, found, err := k.resolveValue(ctx, kind, index, msg)
+			if err == nil && !found && !k.allowMissing {
+				err = errors.New("key not found")
+			}
+			if err != nil {
+				err = fmt.Errorf("failed to resolve %s value: %v", operator, err)
+				k.log.Errorf("failed to process message: %v", err)
+				return err
+			}
+			// missing values leave the message unchanged when allowed
+			if !found {
+				return nil
+			}
+			if k.valueRef.metadata != "" {
+				part.Metadata().Set(k.valueRef.metadata, string(value))
+				return nil
+			}
+			part.Set(value)
+			return nil
+		}
+
+		// the diff operator works with documents that are not themselves
+		// kubernetes objects
+		if operator == "diff" {
+			k.log.Debugln("diffing kubernetes objects")
+			delta, changed, err := k.diff(part.Get())
+			if err != nil {
+				err = fmt.Errorf("failed to diff objects: %v", err)
+				k.log.Errorf("failed to process message: %v", err)
+				return err
+			}
+			part.Metadata().Set("diff_changed", strconv.FormatBool(changed))
+			part.Set(delta)
+			return nil
+		}
+
+		// render the object from the message contents if configured
+		body := part.Get()
+		if k.render != nil {
+			if body, err = k.renderObject(index, msg); err != nil {
+				err = fmt.Errorf("failed to render object: %v", err)
+				k.log.Errorf("failed to process message: %v", err)
+				return err
+			}
+		}
+
+		var u unstructured.Unstructured
+		if err := u.UnmarshalJSON(body); err != nil {
+			return fmt.Errorf("invalid message part, must be valid kubernetes runtime object: %v", err)
+		}
+		id := fmt.Sprintf("%s Namespace=%s Name=%s", u.GetObjectKind().GroupVersionKind().String(), u.GetNamespace(), u.GetName())
+
 		switch operator {
+		case "drain":
+			k.log.Debugf("draining kubernetes node: %s", id)
+			if u.GetKind() != "Node" {
+				err = fmt.Errorf("failed to drain node: unsupported kind: %s", u.GetKind())
+				break
+			}
+			var evicted []string
+			evicted, err = k.drain(ctx, u.GetName())
+			if b, jerr := json.Marshal(evicted); jerr == nil && evicted != nil {
+				part.Metadata().Set("evicted_pods", string(b))
+			}
+			if err != nil {
+				err = fmt.Errorf("failed to drain node: %v", err)
+			}
+		case "endpoints":
+			k.log.Debugf("resolving kubernetes service endpoints: %s", id)
+			if u.GetKind() != "Service" {
+				err = fmt.Errorf("failed to resolve endpoints: unsupported kind: %s", u.GetKind())
+				break
+			}
+			var addrs []endpointAddress
+			if addrs, err = k.endpoints(ctx, u.GetNamespace(), u.GetName()); err != nil {
+				err = fmt.Errorf("failed to resolve endpoints: %v", err)
+				break
+			}
+			var b []byte
+			if b, err = json.Marshal(addrs); err != nil {
+				err = fmt.Errorf("failed to resolve endpoints: %v", err)
+				break
+			}
+			if k.endpointsConf.Metadata != "" {
+				part.Metadata().Set(k.endpointsConf.Metadata, string(b))
+				break
+			}
+			result = b
+		case "events":
+			k.log.Debugf("listing kubernetes object events: %s", id)
+			var events []corev1.Event
+			if events, err = k.events(ctx, &u); err != nil {
+				err = fmt.Errorf("failed to list events: %v", err)
+				break
+			}
+			part.Metadata().Set("event_count", strconv.Itoa(len(events)))
+			if k.eventsConf.Field == "" {
+				if result, err = json.Marshal(events); err != nil {
+					err = fmt.Errorf("failed to list events: %v", err)
+				}
+				break
+			}
+			content := make([]interface{}, 0, len(events))
+			for i := range events {
+				obj, cerr := runtime.DefaultUnstructuredConverter.ToUnstructured(&events[i])
+				if cerr != nil {
+					err = fmt.Errorf("failed to list events: error converting event: %v", cerr)
+					break
+				}
+				content = append(content, obj)
+			}
+			if err != nil {
+				break
+			}
+			if err = unstructured.SetNestedSlice(u.Object, content, strings.Split(k.eventsConf.Field, ".")...); err != nil {
+				err = fmt.Errorf("failed to list events: error setting field: %v", err)
+			}
 		case "get":
 			k.log.Debugf("getting kubernetes object: %s", id)
 			key, perr := client.ObjectKeyFromObject(&u)
-			if err != nil {
+			if perr != nil {
 				err = fmt.Errorf("failed to get object: failed to get object key from object: %v", perr)
 				break
 			}
-			if err = k.client.Get(ctx, key, &u); err != nil {
+			if err = k.get(ctx, key, &u); err != nil {
 				err = fmt.Errorf("failed to get object: %v", err)
+				break
+			}
+			if k.extract != nil {
+				if result, err = k.extractValue(part, &u); err != nil {
+					err = fmt.Errorf("failed to get object: %v", err)
+				}
 			}
 		case "create":
 			k.log.Debugf("creating kubernetes object: %s", id)
-			if err = k.client.Create(ctx, &u); err != nil {
+			var c client.Client
+			if c, err = k.resource.Client(); err == nil {
+				err = c.Create(ctx, &u)
+			}
+			if err != nil {
 				err = fmt.Errorf("failed to create object: %v", err)
 			}
 		case "update":
 			k.log.Debugf("updating kubernetes object: %s", id)
-			if err = k.client.Update(ctx, &u); err != nil {
+			var c client.Client
+			if c, err = k.resource.Client(); err == nil {
+				err = c.Update(ctx, &u)
+			}
+			if err != nil {
 				err = fmt.Errorf("failed to update object: %v", err)
 			}
 		case "delete":
@@ -180,14 +549,234 @@ func (k *Kubernetes) ProcessMessage(msg types.Message) ([]types.Message, types.R
 				PropagationPolicy: &policy,
 			})
 
-			if err = k.client.Delete(ctx, &u, opts...); err != nil {
+			var c client.Client
+			if c, err = k.resource.Client(); err == nil {
+				err = c.Delete(ctx, &u, opts...)
+			}
+			if err != nil {
 				err = fmt.Errorf("failed to delete object: %v", err)
 			}
+		case "image_digests":
+			k.log.Debugf("resolving kubernetes pod image digests: %s", id)
+			if gvk := u.GroupVersionKind(); gvk.Group != "" || gvk.Kind != "Pod" {
+				err = fmt.Errorf("failed to resolve image digests: unsupported kind: %s", gvk.String())
+				break
+			}
+			var digests []imageDigest
+			if digests, err = imageDigests(&u); err != nil {
+				err = fmt.Errorf("failed to resolve image digests: %v", err)
+				break
+			}
+			var b []byte
+			if b, err = json.Marshal(digests); err != nil {
+				err = fmt.Errorf("failed to resolve image digests: %v", err)
+				break
+			}
+			if k.imageDigestsConf.Metadata != "" {
+				part.Metadata().Set(k.imageDigestsConf.Metadata, string(b))
+				break
+			}
+			result = b
+		case "node":
+			k.log.Debugf("getting kubernetes pod node: %s", id)
+			if gvk := u.GroupVersionKind(); gvk.Group != "" || gvk.Kind != "Pod" {
+				err = fmt.Errorf("failed to get node: unsupported kind: %s", gvk.String())
+				break
+			}
+			var node *unstructured.Unstructured
+			node, err = k.node(ctx, &u)
+			// pods that are not yet scheduled, or whose node no longer
+			// exists when allowed, pass through unchanged
+			if err == errNotScheduled || (k.allowMissing && apierrors.IsNotFound(err)) {
+				k.log.Debugf("skipping node of %s: %v", id, err)
+				return nil
+			}
+			if err != nil {
+				err = fmt.Errorf("failed to get node: %v", err)
+				break
+			}
+			part.Metadata().Set("node_name", node.GetName())
+			if err = unstructured.SetNestedField(u.Object, node.Object, strings.Split(k.nodeConf.Field, ".")...); err != nil {
+				err = fmt.Errorf("failed to get node: error setting field: %v", err)
+			}
+		case "owner":
+			k.log.Debugf("getting kubernetes object owner: %s", id)
+			var owner *unstructured.Unstructured
+			if owner, err = k.owner(ctx, &u); err != nil {
+				err = fmt.Errorf("failed to get owner: %v", err)
+				break
+			}
+			if k.ownerConf.Field == "" {
+				u = *owner
+				break
+			}
+			if err = unstructured.SetNestedField(u.Object, owner.Object, strings.Split(k.ownerConf.Field, ".")...); err != nil {
+				err = fmt.Errorf("failed to get owner: error setting field: %v", err)
+			}
+		case "scale":
+			k.log.Debugf("scaling kubernetes object: %s", id)
+			if k.replicas == nil {
+				err = errors.New("failed to scale object: replicas must be specified")
+				break
+			}
+			var replicas int64
+			replicas, err = strconv.ParseInt(k.replicas.String(index, msg), 10, 32)
+			if err != nil {
+				err = fmt.Errorf("failed to scale object: invalid replicas: %v", err)
+				break
+			}
+			var observed int64
+			if observed, err = k.scale(ctx, &u, int32(replicas)); err != nil {
+				err = fmt.Errorf("failed to scale object: %v", err)
+				break
+			}
+			part.Metadata().Set("replicas", strconv.FormatInt(observed, 10))
+		case "rollout_restart":
+			k.log.Debugf("restarting kubernetes workload rollout: %s", id)
+			if gvk := u.GroupVersionKind(); gvk.Group != "apps" || !rolloutKinds[gvk.Kind] {
+				err = fmt.Errorf("failed to restart rollout: unsupported kind: %s", gvk.String())
+				break
+			}
+			var restartedAt string
+			if restartedAt, err = k.rolloutRestart(ctx, &u); err != nil {
+				err = fmt.Errorf("failed to restart rollout: %v", err)
+				break
+			}
+			part.Metadata().Set("restarted_at", restartedAt)
+		case "run_job":
+			k.log.Debugf("running kubernetes job: %s", id)
+			if gvk := u.GroupVersionKind(); gvk.Group != "batch" || gvk.Kind != "Job" {
+				err = fmt.Errorf("failed to run job: unsupported kind: %s", gvk.String())
+				break
+			}
+			var res *jobResult
+			if res, err = k.runJob(ctx, &u); err != nil {
+				err = fmt.Errorf("failed to run job: %v", err)
+				break
+			}
+			content, cerr := runtime.DefaultUnstructuredConverter.ToUnstructured(res.job)
+			if cerr != nil {
+				err = fmt.Errorf("failed to run job: error converting job: %v", cerr)
+				break
+			}
+			u.Object = content
+			u.SetAPIVersion("batch/v1")
+			u.SetKind("Job")
+			part.Metadata().Set("job_status", res.status)
+			if k.runJobConf.Logs {
+				part.Metadata().Set("job_logs", res.logs)
+			}
+			if res.status != jobStatusSucceeded {
+				if b, merr := u.MarshalJSON(); merr == nil {
+					part.Set(b)
+				}
+				err = fmt.Errorf("failed to run job: job %s/%s %s", u.GetNamespace(), u.GetName(), res.status)
+			}
+		case "taint", "untaint":
+			remove := operator == "untaint"
+			k.log.Debugf("%sing kubernetes node: %s", operator, id)
+			if gvk := u.GroupVersionKind(); gvk.Group != "" || gvk.Kind != "Node" {
+				err = fmt.Errorf("failed to %s node: unsupported kind: %s", operator, gvk.String())
+				break
+			}
+			var taint corev1.Taint
+			if taint, err = k.taint.resolve(index, msg, remove); err != nil {
+				err = fmt.Errorf("failed to %s node: %v", operator, err)
+				break
+			}
+			var node *corev1.Node
+			if node, err = k.setTaint(ctx, u.GetName(), taint, remove); err != nil {
+				err = fmt.Errorf("failed to %s node: %v", operator, err)
+				break
+			}
+			taints := node.Spec.Taints
+			if taints == nil {
+				taints = []corev1.Taint{}
+			}
+			if b, jerr := json.Marshal(taints); jerr == nil {
+				part.Metadata().Set("taints", string(b))
+			}
+			content, cerr := runtime.DefaultUnstructuredConverter.ToUnstructured(node)
+			if cerr != nil {
+				err = fmt.Errorf("failed to %s node: error converting node: %v", operator, cerr)
+				break
+			}
+			u.Object = content
+			u.SetAPIVersion("v1")
+			u.SetKind("Node")
+		case "token_request":
+			k.log.Debugf("requesting kubernetes service account token: %s", id)
+			if u.GetKind() != "ServiceAccount" {
+				err = fmt.Errorf("failed to request token: unsupported kind: %s", u.GetKind())
+				break
+			}
+			var token *authenticationv1.TokenRequest
+			if token, err = k.tokenRequest(ctx, u.GetNamespace(), u.GetName()); err != nil {
+				err = fmt.Errorf("failed to request token: %v", err)
+				break
+			}
+			part.Metadata().Set("token", token.Status.Token)
+			part.Metadata().Set("token_expiration", token.Status.ExpirationTimestamp.UTC().Format(time.RFC3339))
+		case "csr_approve", "csr_deny":
+			approve, action := operator == "csr_approve", "approve"
+			if !approve {
+				action = "deny"
+			}
+			k.log.Debugf("%s kubernetes certificate signing request: %s", action, id)
+			if gvk := u.GroupVersionKind(); gvk.GroupKind() != csrGroupKind {
+				err = fmt.Errorf("failed to %s certificate signing request: unsupported kind: %s", action, gvk.String())
+				break
+			}
+			var csr *unstructured.Unstructured
+			if csr, err = k.decideCSR(ctx, u.GetName(), approve, index, msg); err != nil {
+				err = fmt.Errorf("failed to %s certificate signing request: %v", action, err)
+				break
+			}
+			u.Object = csr.Object
+		case "set_condition":
+			k.log.Debugf("setting kubernetes object status condition: %s", id)
+			var cond condition
+			if cond, err = k.condition.resolve(index, msg); err != nil {
+				err = fmt.Errorf("failed to set condition: %v", err)
+				break
+			}
+			if err = k.setCondition(ctx, &u, cond); err != nil {
+				err = fmt.Errorf("failed to set condition: %v", err)
+			}
 		case "status":
 			k.log.Debugf("updating kubernetes object status: %s", id)
-			if err = k.client.Status().Update(ctx, &u); err != nil {
+			var c client.Client
+			if c, err = k.resource.Client(); err == nil {
+				err = c.Status().Update(ctx, &u)
+			}
+			if err != nil {
 				err = fmt.Errorf("failed to update object status: %v", err)
 			}
+		case "validate":
+			k.log.Debugf("validating kubernetes object: %s", id)
+			var errs []validationError
+			if errs, err = k.validate(ctx, &u); err != nil {
+				err = fmt.Errorf("failed to validate object: %v", err)
+				break
+			}
+			part.Metadata().Set("valid", strconv.FormatBool(len(errs) == 0))
+			if len(errs) > 0 {
+				if b, jerr := json.Marshal(errs); jerr == nil {
+					part.Metadata().Set("validation_errors", string(b))
+				}
+				err = fmt.Errorf("failed to validate object: %s", formatValidationErrors(errs))
+			}
+			// the message body is left unchanged rather than replaced with
+			// the defaulted dry-run result
+			result = body
+		case "watch_one":
+			k.log.Debugf("watching kubernetes object: %s", id)
+			if err = k.watchOne(ctx, &u, part); err != nil {
+				if b, merr := u.MarshalJSON(); merr == nil {
+					part.Set(b)
+				}
+				err = fmt.Errorf("failed to watch object: %v", err)
+			}
 		default:
 			k.log.Errorf("unsupported operator: %s", operator)
 			return fmt.Errorf("unsupported operator: %s", operator)
@@ -198,6 +787,11 @@ func (k *Kubernetes) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			return err
 		}
 
+		if result != nil {
+			part.Set(result)
+			return nil
+		}
+
 		b, err := u.MarshalJSON()
 		if err != nil {
 			err = fmt.Errorf("failed to parse result object: %v", err)
@@ -213,6 +807,77 @@ func (k *Kubernetes) ProcessMessage(msg types.Message) ([]types.Message, types.R
 	return []types.Message{newMsg}, nil
 }
 
+// extractValue evaluates the extract mapping against a fetched object,
+// returning an error if the result is null unless allow_missing is set
+func (k *Kubernetes) extractValue(part types.Part, u *unstructured.Unstructured) ([]byte, error) {
+	b, err := u.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling object: %v", err)
+	}
+	p := part.Copy()
+	p.Set(b)
+
+	msg := message.New(nil)
+	msg.Append(p)
+
+	res, err := k.extract.MapPart(0, msg)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating extract mapping: %v", err)
+	}
+	if res == nil || string(res.Get()) == "null" {
+		if !k.allowMissing {
+			return nil, errors.New("extract mapping returned no value")
+		}
+		return []byte("null"), nil
+	}
+	return res.Get(), nil
+}
+
+// scale updates the scale subresource of the given object, returning the
+// resulting observed replica count
+func (k *Kubernetes) scale(ctx context.Context, u *unstructured.Unstructured, replicas int32) (int64, error) {
+	gvk := u.GroupVersionKind()
+	mapper, err := k.resource.Mapper()
+	if err != nil {
+		return 0, err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return 0, fmt.Errorf("error mapping resource: %v", err)
+	}
+	dynamicClient, err := k.resource.Dynamic()
+	if err != nil {
+		return 0, err
+	}
+	rc := dynamicClient.Resource(mapping.Resource).Namespace(u.GetNamespace())
+
+	scale, err := rc.Get(ctx, u.GetName(), metav1.GetOptions{}, "scale")
+	if err != nil {
+		// distinguish between a missing object and a kind that does not
+		// support the scale subresource
+		if apierrors.IsNotFound(err) {
+			if _, gerr := rc.Get(ctx, u.GetName(), metav1.GetOptions{}); gerr == nil {
+				return 0, fmt.Errorf("kind %s does not support the scale subresource", gvk.Kind)
+			}
+		}
+		return 0, err
+	}
+
+	if err := unstructured.SetNestedField(scale.Object, int64(replicas), "spec", "replicas"); err != nil {
+		return 0, err
+	}
+	scale, err = rc.Update(ctx, scale, metav1.UpdateOptions{}, "scale")
+	if err != nil {
+		return 0, err
+	}
+
+	observed, _, err := unstructured.NestedInt64(scale.Object, "status", "replicas")
+	if err != nil {
+		return 0, fmt.Errorf("error reading observed replicas: %v", err)
+	}
+	return observed, nil
+}
+
 // CloseAsync shuts down the processor and stops processing requests.
 func (k *Kubernetes) CloseAsync() {
 }
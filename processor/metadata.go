@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// TargetConfig identifies the object targeted by the label and annotate
+// operators, where each field supports interpolation functions
+type TargetConfig struct {
+	APIVersion string `json:"api_version" yaml:"api_version"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Name       string `json:"name" yaml:"name"`
+	Namespace  string `json:"namespace" yaml:"namespace"`
+}
+
+// NewTargetConfig returns a TargetConfig with default values
+func NewTargetConfig() TargetConfig {
+	return TargetConfig{}
+}
+
+// targetFields contains the parsed interpolation fields of a target
+type targetFields struct {
+	apiVersion bloblang.Field
+	kind       bloblang.Field
+	name       bloblang.Field
+	namespace  bloblang.Field
+}
+
+func newTargetFields(conf TargetConfig) (*targetFields, error) {
+	var t targetFields
+	var err error
+	if t.apiVersion, err = bloblang.NewField(conf.APIVersion); err != nil {
+		return nil, fmt.Errorf("error parsing api_version: %v", err)
+	}
+	if t.kind, err = bloblang.NewField(conf.Kind); err != nil {
+		return nil, fmt.Errorf("error parsing kind: %v", err)
+	}
+	if t.name, err = bloblang.NewField(conf.Name); err != nil {
+		return nil, fmt.Errorf("error parsing name: %v", err)
+	}
+	if t.namespace, err = bloblang.NewField(conf.Namespace); err != nil {
+		return nil, fmt.Errorf("error parsing namespace: %v", err)
+	}
+	return &t, nil
+}
+
+// resolve returns the object identified by the target fields, falling back to
+// the object contained in the message part for any empty fields
+func (t *targetFields) resolve(index int, msg types.Message) (*unstructured.Unstructured, error) {
+	var fallback unstructured.Unstructured
+	_ = json.Unmarshal(msg.Get(index).Get(), &fallback.Object)
+
+	apiVersion := t.apiVersion.String(index, msg)
+	if apiVersion == "" {
+		apiVersion = fallback.GetAPIVersion()
+	}
+	kind := t.kind.String(index, msg)
+	if kind == "" {
+		kind = fallback.GetKind()
+	}
+	name := t.name.String(index, msg)
+	if name == "" {
+		name = fallback.GetName()
+	}
+	namespace := t.namespace.String(index, msg)
+	if namespace == "" {
+		namespace = fallback.GetNamespace()
+	}
+	if apiVersion == "" || kind == "" || name == "" {
+		return nil, errors.New("target api_version, kind, and name are required")
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api_version: %v", err)
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gv.WithKind(kind))
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u, nil
+}
+
+// parseNullableFields parses a map of interpolated strings, where nil values
+// are preserved to indicate keys that should be removed
+func parseNullableFields(raw map[string]*string) (map[string]bloblang.Field, error) {
+	fields := make(map[string]bloblang.Field, len(raw))
+	for k, v := range raw {
+		if v == nil {
+			fields[k] = nil
+			continue
+		}
+		f, err := bloblang.NewField(*v)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", k, err)
+		}
+		fields[k] = f
+	}
+	return fields, nil
+}
+
+//------------------------------------------------------------------------------
+
+// patchMetadata applies a JSON merge patch to the labels or annotations of the
+// given object, such that other keys are left untouched and nil fields remove
+// the corresponding keys
+func (k *Kubernetes) patchMetadata(ctx context.Context, u *unstructured.Unstructured, field string, values map[string]bloblang.Field, index int, msg types.Message) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no %s specified", field)
+	}
+
+	entries := make(map[string]interface{}, len(values))
+	for key, f := range values {
+		if f == nil {
+			entries[key] = nil
+			continue
+		}
+		entries[key] = f.String(index, msg)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			field: entries,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding patch: %v", err)
+	}
+	c, err := k.resource.Client()
+	if err != nil {
+		return err
+	}
+	return c.Patch(ctx, u, client.RawPatch(ktypes.MergePatchType, patch))
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// NodeConfig defines runtime configuration for the node operator
+type NodeConfig struct {
+	Field  string   `json:"field" yaml:"field"`
+	Fields []string `json:"fields" yaml:"fields"`
+	Labels []string `json:"labels" yaml:"labels"`
+}
+
+// NewNodeConfig returns a NodeConfig with default values
+func NewNodeConfig() NodeConfig {
+	return NodeConfig{
+		Field:  "node",
+		Fields: []string{},
+		Labels: []string{},
+	}
+}
+
+// errNotScheduled indicates that a pod has not been assigned to a node
+var errNotScheduled = errors.New("pod is not scheduled")
+
+//------------------------------------------------------------------------------
+
+// node fetches the node running the given pod, returning a partial node
+// containing the name of the node, its selected labels, and any additional
+// selected fields
+func (k *Kubernetes) node(ctx context.Context, pod *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	nodeName, _, err := unstructured.NestedString(pod.Object, "spec", "nodeName")
+	if err != nil {
+		return nil, fmt.Errorf("error reading node name: %v", err)
+	}
+	if nodeName == "" {
+		return nil, errNotScheduled
+	}
+
+	node := &unstructured.Unstructured{}
+	node.SetAPIVersion("v1")
+	node.SetKind("Node")
+	if err := k.get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return nil, err
+	}
+
+	result := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	result.SetName(node.GetName())
+	nodeLabels := node.GetLabels()
+	if len(k.nodeConf.Labels) > 0 {
+		selected := map[string]string{}
+		for _, key := range k.nodeConf.Labels {
+			if value, ok := nodeLabels[key]; ok {
+				selected[key] = value
+			}
+		}
+		nodeLabels = selected
+	}
+	result.SetLabels(nodeLabels)
+	for _, field := range k.nodeConf.Fields {
+		path := strings.Split(field, ".")
+		value, found, err := unstructured.NestedFieldCopy(node.Object, path...)
+		if err != nil {
+			return nil, fmt.Errorf("error reading field %s: %v", field, err)
+		}
+		if !found {
+			continue
+		}
+		if err := unstructured.SetNestedField(result.Object, value, path...); err != nil {
+			return nil, fmt.Errorf("error setting field %s: %v", field, err)
+		}
+	}
+	return result, nil
+}
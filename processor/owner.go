@@ -0,0 +1,103 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// OwnerConfig defines runtime configuration for the owner operator
+type OwnerConfig struct {
+	Field     string `json:"field" yaml:"field"`
+	MaxDepth  int    `json:"max_depth" yaml:"max_depth"`
+	Recursive bool   `json:"recursive" yaml:"recursive"`
+}
+
+// NewOwnerConfig returns an OwnerConfig with default values
+func NewOwnerConfig() OwnerConfig {
+	return OwnerConfig{
+		MaxDepth: 10,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// owner resolves the controller owner of the given object, optionally
+// recursing to the top-level owner
+func (k *Kubernetes) owner(ctx context.Context, u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	visited := map[types.UID]struct{}{}
+	if uid := u.GetUID(); uid != "" {
+		visited[uid] = struct{}{}
+	}
+
+	current := u
+	var owner *unstructured.Unstructured
+	for depth := 0; ; depth++ {
+		ref := metav1.GetControllerOf(current)
+		if ref == nil {
+			if owner == nil {
+				return nil, errors.New("object has no controller owner")
+			}
+			return owner, nil
+		}
+		if k.ownerConf.MaxDepth > 0 && depth >= k.ownerConf.MaxDepth {
+			return nil, fmt.Errorf("exceeded max depth of %d", k.ownerConf.MaxDepth)
+		}
+		if _, ok := visited[ref.UID]; ok {
+			return nil, fmt.Errorf("owner reference cycle detected at %s %s", ref.Kind, ref.Name)
+		}
+		visited[ref.UID] = struct{}{}
+
+		next, err := k.getOwner(ctx, current.GetNamespace(), ref)
+		if err != nil {
+			return nil, fmt.Errorf("error getting owner %s %s: %v", ref.Kind, ref.Name, err)
+		}
+		owner = next
+		if !k.ownerConf.Recursive {
+			return owner, nil
+		}
+		current = next
+	}
+}
+
+// getOwner fetches the object identified by an owner reference, which must
+// either be cluster scoped or reside in the same namespace as its dependent
+func (k *Kubernetes) getOwner(ctx context.Context, namespace string, ref *metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+	gvk := gv.WithKind(ref.Kind)
+
+	mapper, err := k.resource.Mapper()
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("error mapping resource: %v", err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = ""
+	}
+
+	owner := &unstructured.Unstructured{}
+	owner.SetGroupVersionKind(gvk)
+	c, err := k.resource.Client()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, owner); err != nil {
+		return nil, err
+	}
+	return owner, nil
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Jeffail/benthos/v3/lib/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+//------------------------------------------------------------------------------
+
+// renderObject renders the manifest of a single object from the message part
+// at the given index, returning the object encoded as JSON
+func (k *Kubernetes) renderObject(index int, msg types.Message) ([]byte, error) {
+	b, err := k.render.Render(index, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc []byte
+	reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for {
+		next, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading rendered manifest: %v", err)
+		}
+		if len(bytes.TrimSpace(next)) == 0 {
+			continue
+		}
+		if doc != nil {
+			return nil, errors.New("rendered manifest contains multiple documents")
+		}
+		doc = next
+	}
+	if doc == nil {
+		return nil, errors.New("rendered manifest is empty")
+	}
+
+	if doc, err = sigsyaml.YAMLToJSON(doc); err != nil {
+		return nil, fmt.Errorf("error parsing rendered manifest: %v", err)
+	}
+	return doc, nil
+}
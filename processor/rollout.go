@@ -0,0 +1,55 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// restartedAtAnnotation is the pod template annotation patched by kubectl
+// rollout restart
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// rolloutKinds contains the kinds of the apps group that support rollout
+// restarts
+var rolloutKinds = map[string]bool{
+	"DaemonSet":   true,
+	"Deployment":  true,
+	"StatefulSet": true,
+}
+
+// rolloutRestart triggers a rolling restart of the given workload by patching
+// its pod template with the current time, in the same manner as kubectl
+// rollout restart, returning the patched annotation value
+func (k *Kubernetes) rolloutRestart(ctx context.Context, u *unstructured.Unstructured) (string, error) {
+	restartedAt := time.Now().UTC().Format(time.RFC3339)
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						restartedAtAnnotation: restartedAt,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding patch: %v", err)
+	}
+	c, err := k.resource.Client()
+	if err != nil {
+		return "", err
+	}
+	if err := c.Patch(ctx, u, client.RawPatch(ktypes.MergePatchType, patch)); err != nil {
+		return "", err
+	}
+	return restartedAt, nil
+}
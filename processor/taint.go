@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// TaintConfig defines runtime configuration for the taint and untaint
+// operators, where each field supports interpolation functions
+type TaintConfig struct {
+	Effect string `json:"effect" yaml:"effect"`
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+}
+
+// NewTaintConfig returns a TaintConfig with default values
+func NewTaintConfig() TaintConfig {
+	return TaintConfig{}
+}
+
+// taintFields contains the parsed interpolation fields of a taint
+type taintFields struct {
+	effect bloblang.Field
+	key    bloblang.Field
+	value  bloblang.Field
+}
+
+func newTaintFields(conf TaintConfig) (*taintFields, error) {
+	var t taintFields
+	var err error
+	if t.effect, err = bloblang.NewField(conf.Effect); err != nil {
+		return nil, fmt.Errorf("error parsing effect: %v", err)
+	}
+	if t.key, err = bloblang.NewField(conf.Key); err != nil {
+		return nil, fmt.Errorf("error parsing key: %v", err)
+	}
+	if t.value, err = bloblang.NewField(conf.Value); err != nil {
+		return nil, fmt.Errorf("error parsing value: %v", err)
+	}
+	return &t, nil
+}
+
+// resolve evaluates the taint fields for a message part, where the effect is
+// optional when removing taints
+func (t *taintFields) resolve(index int, msg types.Message, remove bool) (corev1.Taint, error) {
+	taint := corev1.Taint{
+		Effect: corev1.TaintEffect(t.effect.String(index, msg)),
+		Key:    t.key.String(index, msg),
+		Value:  t.value.String(index, msg),
+	}
+	if taint.Key == "" {
+		return taint, errors.New("taint key is required")
+	}
+	switch taint.Effect {
+	case corev1.TaintEffectNoExecute, corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule:
+	case "":
+		if !remove {
+			return taint, errors.New("taint effect is required")
+		}
+	default:
+		return taint, fmt.Errorf("invalid taint effect: %q, must be one of NoSchedule, PreferNoSchedule, NoExecute", taint.Effect)
+	}
+	return taint, nil
+}
+
+//------------------------------------------------------------------------------
+
+// setTaint adds or removes a taint of the named node, returning the updated
+// node. Taints are identified by their key and effect, such that adding an
+// existing taint replaces its value, and removing a taint without an effect
+// removes every taint with its key, in the same manner as kubectl taint. The
+// node is only updated if its taints change.
+func (k *Kubernetes) setTaint(ctx context.Context, name string, taint corev1.Taint, remove bool) (*corev1.Node, error) {
+	c, err := k.resource.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	var node corev1.Node
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := c.Get(ctx, client.ObjectKey{Name: name}, &node); err != nil {
+			return err
+		}
+
+		var taints []corev1.Taint
+		var changed bool
+		if remove {
+			taints, changed = removeTaint(node.Spec.Taints, taint)
+		} else {
+			taints, changed = addTaint(node.Spec.Taints, taint)
+		}
+		if !changed {
+			return nil
+		}
+		node.Spec.Taints = taints
+		return c.Update(ctx, &node)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// addTaint adds a taint to a list of taints, replacing the value of an
+// existing taint with the same key and effect
+func addTaint(taints []corev1.Taint, taint corev1.Taint) ([]corev1.Taint, bool) {
+	result := make([]corev1.Taint, 0, len(taints)+1)
+	var found, changed bool
+	for _, t := range taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect {
+			found = true
+			if t.Value != taint.Value {
+				t.Value, changed = taint.Value, true
+			}
+		}
+		result = append(result, t)
+	}
+	if !found {
+		result, changed = append(result, taint), true
+	}
+	return result, changed
+}
+
+// removeTaint removes the taints with the key of the given taint, restricted
+// to its effect if specified
+func removeTaint(taints []corev1.Taint, taint corev1.Taint) ([]corev1.Taint, bool) {
+	result := make([]corev1.Taint, 0, len(taints))
+	for _, t := range taints {
+		if t.Key == taint.Key && (taint.Effect == "" || t.Effect == taint.Effect) {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result, len(result) != len(taints)
+}
@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+//------------------------------------------------------------------------------
+
+// TokenRequestConfig defines runtime configuration for the token_request
+// operator
+type TokenRequestConfig struct {
+	Audiences  []string `json:"audiences" yaml:"audiences"`
+	Expiration string   `json:"expiration" yaml:"expiration"`
+}
+
+// NewTokenRequestConfig returns a TokenRequestConfig with default values
+func NewTokenRequestConfig() TokenRequestConfig {
+	return TokenRequestConfig{
+		Audiences:  []string{},
+		Expiration: "1h",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// tokenRequest requests a short-lived token for the given service account
+func (k *Kubernetes) tokenRequest(ctx context.Context, namespace, name string) (*authenticationv1.TokenRequest, error) {
+	clientset, err := k.resource.Clientset()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences: k.tokenRequestConf.Audiences,
+		},
+	}
+	if k.tokenExpiration > 0 {
+		seconds := int64(k.tokenExpiration / time.Second)
+		req.Spec.ExpirationSeconds = &seconds
+	}
+
+	res, err := clientset.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, name, req, metav1.CreateOptions{})
+	if err != nil {
+		if apierrors.IsForbidden(err) {
+			return nil, fmt.Errorf("permission denied, requires create permission on the serviceaccounts/token subresource: %v", err)
+		}
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// validateFieldManager is the field manager of dry-run applies performed by
+// the validate operator
+const validateFieldManager = "benthos-validate"
+
+// validationError describes a single field rejected by the API server
+type validationError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+}
+
+//------------------------------------------------------------------------------
+
+// validate validates an object against the schema served by the API server
+// using a dry-run server-side apply, which runs admission and validation
+// without persisting the object. Returns the field errors if the object is
+// rejected as invalid, or an error if validation could not be performed.
+func (k *Kubernetes) validate(ctx context.Context, u *unstructured.Unstructured) ([]validationError, error) {
+	obj := u.DeepCopy()
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+
+	// conflicts with other field managers are not schema errors, so the
+	// dry-run forces ownership of the applied fields
+	c, err := k.resource.Client()
+	if err != nil {
+		return nil, err
+	}
+	err = c.Patch(ctx, obj, client.Apply, client.DryRunAll, client.ForceOwnership, client.FieldOwner(validateFieldManager))
+	if err == nil {
+		return nil, nil
+	}
+	if !apierrors.IsInvalid(err) && !apierrors.IsBadRequest(err) {
+		return nil, err
+	}
+
+	status, ok := err.(apierrors.APIStatus)
+	if !ok {
+		return nil, err
+	}
+	var errs []validationError
+	if details := status.Status().Details; details != nil {
+		for _, cause := range details.Causes {
+			errs = append(errs, validationError{
+				Field:   cause.Field,
+				Message: cause.Message,
+				Type:    string(cause.Type),
+			})
+		}
+	}
+	// errors such as undeclared fields are reported without causes
+	if len(errs) == 0 {
+		errs = append(errs, validationError{Message: status.Status().Message})
+	}
+	return errs, nil
+}
+
+// formatValidationErrors formats validation errors for inclusion in an error
+// message
+func formatValidationErrors(errs []validationError) string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+		if e.Field != "" && !strings.Contains(e.Message, e.Field) {
+			msgs[i] = e.Field + ": " + e.Message
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//------------------------------------------------------------------------------
+
+// ValueConfig identifies the ConfigMap or Secret key resolved by the configmap
+// and secret operators, where the key, name, and namespace fields support
+// interpolation functions
+type ValueConfig struct {
+	Key       string `json:"key" yaml:"key"`
+	Metadata  string `json:"metadata" yaml:"metadata"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+}
+
+// NewValueConfig returns a ValueConfig with default values
+func NewValueConfig() ValueConfig {
+	return ValueConfig{}
+}
+
+// valueFields contains the parsed interpolation fields of a value reference
+type valueFields struct {
+	key       bloblang.Field
+	metadata  string
+	name      bloblang.Field
+	namespace bloblang.Field
+}
+
+func newValueFields(conf ValueConfig) (*valueFields, error) {
+	v := valueFields{metadata: conf.Metadata}
+	var err error
+	if v.key, err = bloblang.NewField(conf.Key); err != nil {
+		return nil, fmt.Errorf("error parsing key: %v", err)
+	}
+	if v.name, err = bloblang.NewField(conf.Name); err != nil {
+		return nil, fmt.Errorf("error parsing name: %v", err)
+	}
+	if v.namespace, err = bloblang.NewField(conf.Namespace); err != nil {
+		return nil, fmt.Errorf("error parsing namespace: %v", err)
+	}
+	return &v, nil
+}
+
+//------------------------------------------------------------------------------
+
+// resolveValue fetches the referenced ConfigMap or Secret and returns the
+// value of the referenced key, base64-decoding Secret and ConfigMap binaryData
+// values, and whether the key exists
+func (k *Kubernetes) resolveValue(ctx context.Context, kind string, index int, msg types.Message) ([]byte, bool, error) {
+	key := k.valueRef.key.String(index, msg)
+	name := k.valueRef.name.String(index, msg)
+	namespace := k.valueRef.namespace.String(index, msg)
+	if key == "" || name == "" || namespace == "" {
+		return nil, false, errors.New("value key, name, and namespace are required")
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind(kind)
+	if err := k.get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, u); err != nil {
+		if apierrors.IsForbidden(err) {
+			resource := "configmaps"
+			if kind == "Secret" {
+				resource = "secrets"
+			}
+			return nil, false, fmt.Errorf("permission denied, requires get permission on %s in namespace %s: %v", resource, namespace, err)
+		}
+		return nil, false, fmt.Errorf("error getting %s %s/%s: %v", kind, namespace, name, err)
+	}
+
+	encoded := kind == "Secret"
+	v, ok, _ := unstructured.NestedString(u.Object, "data", key)
+	if !ok && kind == "ConfigMap" {
+		v, ok, _ = unstructured.NestedString(u.Object, "binaryData", key)
+		encoded = true
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	if !encoded {
+		return []byte(v), true, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, false, fmt.Errorf("error decoding value of key %s: %v", key, err)
+	}
+	return b, true, nil
+}
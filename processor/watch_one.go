@@ -0,0 +1,152 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/v3/lib/message"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+//------------------------------------------------------------------------------
+
+// WatchOneConfig defines runtime configuration for the watch_one operator
+type WatchOneConfig struct {
+	Check   string `json:"check" yaml:"check"`
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// NewWatchOneConfig returns a WatchOneConfig with default values
+func NewWatchOneConfig() WatchOneConfig {
+	return WatchOneConfig{
+		Timeout: "5m",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// watchOne watches a single object until its state satisfies the configured
+// check, updating the given object with the latest observed state
+func (k *Kubernetes) watchOne(ctx context.Context, u *unstructured.Unstructured, part types.Part) error {
+	if k.watchOneCheck == nil {
+		return errors.New("check must be specified")
+	}
+
+	gvk := u.GroupVersionKind()
+	mapper, err := k.resource.Mapper()
+	if err != nil {
+		return err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("error mapping resource: %v", err)
+	}
+	dynamicClient, err := k.resource.Dynamic()
+	if err != nil {
+		return err
+	}
+	rc := dynamicClient.Resource(mapping.Resource).Namespace(u.GetNamespace())
+
+	if k.watchOneTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, k.watchOneTimeout)
+		defer cancel()
+	}
+
+	name := u.GetName()
+	for {
+		// (re)establish the watch from the current state of the object, which
+		// is checked prior to watching for changes
+		current, err := rc.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out waiting for check to pass: %v", ctx.Err())
+			}
+			return fmt.Errorf("error getting object: %v", err)
+		}
+		u.Object = current.Object
+		if ok, err := k.checkObject(part, u); err != nil || ok {
+			return err
+		}
+
+		done, err := k.watchOnce(ctx, rc, u, part)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// watchOnce consumes a single watch of an object starting from its current
+// resource version, returning true once the check passes, or false if the
+// watch must be reestablished
+func (k *Kubernetes) watchOnce(ctx context.Context, rc dynamic.ResourceInterface, u *unstructured.Unstructured, part types.Part) (bool, error) {
+	w, err := rc.Watch(ctx, metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", u.GetName()).String(),
+		ResourceVersion: u.GetResourceVersion(),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, fmt.Errorf("timed out waiting for check to pass: %v", ctx.Err())
+		}
+		return false, fmt.Errorf("error watching object: %v", err)
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("timed out waiting for check to pass: %v", ctx.Err())
+		case e, open := <-w.ResultChan():
+			if !open {
+				return false, nil
+			}
+			switch e.Type {
+			case watch.Added, watch.Modified:
+				obj, ok := e.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				u.Object = obj.Object
+				if ok, err := k.checkObject(part, u); err != nil || ok {
+					return ok, err
+				}
+			case watch.Deleted:
+				return false, errors.New("object was deleted")
+			case watch.Error:
+				// expired resource versions are recovered by reestablishing
+				// the watch from the current state
+				err := apierrors.FromObject(e.Object)
+				if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+					return false, nil
+				}
+				return false, fmt.Errorf("error watching object: %v", err)
+			}
+		}
+	}
+}
+
+// checkObject evaluates the watch_one check against the given object
+func (k *Kubernetes) checkObject(part types.Part, u *unstructured.Unstructured) (bool, error) {
+	b, err := u.MarshalJSON()
+	if err != nil {
+		return false, fmt.Errorf("error marshalling object: %v", err)
+	}
+	p := part.Copy()
+	p.Set(b)
+
+	msg := message.New(nil)
+	msg.Append(p)
+
+	ok, err := k.watchOneCheck.QueryPart(0, msg)
+	if err != nil {
+		return false, fmt.Errorf("error evaluating check: %v", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,157 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Jeffail/benthos/v3/lib/bloblang"
+	"github.com/Jeffail/benthos/v3/lib/types"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+//------------------------------------------------------------------------------
+
+// Config defines runtime configuration for rendering manifests from messages
+type Config struct {
+	Mapping  string `json:"mapping" yaml:"mapping"`
+	Template string `json:"template" yaml:"template"`
+}
+
+// NewConfig returns a Config with default values
+func NewConfig() Config {
+	return Config{}
+}
+
+//------------------------------------------------------------------------------
+
+// Renderer renders a manifest from a message part using either a Go template
+// or a Bloblang mapping
+type Renderer struct {
+	mapping  bloblang.Mapping
+	template *template.Template
+}
+
+// New returns a Renderer for the given config, or nil if neither a mapping nor
+// a template is specified
+func New(conf Config) (*Renderer, error) {
+	switch {
+	case conf.Mapping != "" && conf.Template != "":
+		return nil, errors.New("render mapping and template cannot be specified together")
+	case conf.Mapping != "":
+		m, err := bloblang.NewMapping(conf.Mapping)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing render mapping: %v", err)
+		}
+		return &Renderer{mapping: m}, nil
+	case conf.Template != "":
+		t, err := template.New("render").Option("missingkey=error").Funcs(funcs).Parse(conf.Template)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing render template: %v", err)
+		}
+		return &Renderer{template: t}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Render returns the manifest rendered from the message part at the given
+// index, which contains one or more JSON or YAML documents
+func (r *Renderer) Render(index int, msg types.Message) ([]byte, error) {
+	if r.mapping != nil {
+		return r.renderMapping(index, msg)
+	}
+	return r.renderTemplate(index, msg)
+}
+
+// renderMapping executes the mapping, where an array result is rendered as a
+// stream of documents
+func (r *Renderer) renderMapping(index int, msg types.Message) ([]byte, error) {
+	p, err := r.mapping.MapPart(index, msg)
+	if err != nil {
+		return nil, fmt.Errorf("error executing render mapping: %v", err)
+	}
+	if p == nil {
+		return nil, errors.New("render mapping deleted the message")
+	}
+
+	b := p.Get()
+	if trimmed := bytes.TrimSpace(b); len(trimmed) == 0 || trimmed[0] != '[' {
+		return b, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(b, &items); err != nil {
+		return nil, fmt.Errorf("error parsing render mapping result: %v", err)
+	}
+	docs := make([][]byte, len(items))
+	for i := range items {
+		docs[i] = items[i]
+	}
+	return bytes.Join(docs, []byte("\n---\n")), nil
+}
+
+// renderTemplate executes the template with the structured contents, raw
+// contents, and metadata of the message part
+func (r *Renderer) renderTemplate(index int, msg types.Message) ([]byte, error) {
+	part := msg.Get(index)
+
+	meta := map[string]string{}
+	part.Metadata().Iter(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+
+	data := map[string]interface{}{
+		"Meta": meta,
+		"Raw":  string(part.Get()),
+	}
+	// non-structured contents are exposed to the template as a string
+	if body, err := part.JSON(); err == nil {
+		data["Body"] = body
+	} else {
+		data["Body"] = string(part.Get())
+	}
+
+	var buf bytes.Buffer
+	if err := r.template.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("error executing render template: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// funcs defines the functions available to render templates
+var funcs = template.FuncMap{
+	"b64enc": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+	},
+	"lower": strings.ToLower,
+	"quote": func(v interface{}) string {
+		b, _ := json.Marshal(fmt.Sprint(v))
+		return string(b)
+	},
+	"toJson": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"toYaml": func(v interface{}) (string, error) {
+		b, err := sigsyaml.Marshal(v)
+		return strings.TrimSuffix(string(b), "\n"), err
+	},
+	"upper": strings.ToUpper,
+}